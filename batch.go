@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	valid "github.com/asaskevich/govalidator"
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+// runBatch checks all targets from the batch file, prints the reports and exits
+func runBatch(ctx context.Context, checker *dnsbl.Checker) {
+	var r io.Reader = os.Stdin
+	if *cfgBatchFile != "-" {
+		f, err := os.Open(*cfgBatchFile)
+		if err != nil {
+			app.Fatalf("%v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	targets, err := readTargets(r, *cfgMaxRange)
+	if err != nil {
+		app.Fatalf("%v", err)
+	}
+
+	batch := checker.CheckBatchContext(ctx, *cfgWhitelist, targets)
+	saveHealthCache(checker)
+
+	if *cfgOutput == "json" {
+		printBatchJSON(batch)
+	} else {
+		printBatchReport(batch)
+	}
+
+	if !*cfgWhitelist {
+		os.Exit(int(batchSeverity(batch)))
+	}
+}
+
+// readTargets reads IP addresses, CIDR ranges and domains from `r`, one per line.
+// Empty lines and lines starting with # are skipped. CIDR ranges are expanded
+// into individual addresses and may contain at most `maxRange` addresses.
+func readTargets(r io.Reader, maxRange int) ([]string, error) {
+	targets := []string{}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		switch {
+		case strings.Contains(line, "/"):
+			ips, err := dnsbl.ExpandCIDR(line, maxRange)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %v", lineNum, err)
+			}
+			targets = append(targets, ips...)
+		case net.ParseIP(line) != nil, valid.IsDNSName(line):
+			targets = append(targets, line)
+		default:
+			return nil, fmt.Errorf("line %v: %q is not a valid IP address, CIDR range or domain name", lineNum, line)
+		}
+	}
+
+	return targets, scanner.Err()
+}
+
+// runRange checks all addresses in the CIDR range, prints the address × list matrix and exits
+func runRange(ctx context.Context, checker *dnsbl.Checker) {
+	batch, err := checker.CheckRangeContext(ctx, *cfgWhitelist, *cfgRange, *cfgMaxRange)
+	if err != nil {
+		app.FatalUsage("%v", err)
+	}
+	saveHealthCache(checker)
+
+	if *cfgOutput == "json" {
+		printRangeJSON(*cfgRange, batch)
+	} else {
+		printRangeMatrix(batch)
+	}
+
+	if !*cfgWhitelist {
+		os.Exit(int(batchSeverity(batch)))
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+func Test_readTargets(t *testing.T) {
+	input := `
+# outbound MTAs
+192.0.2.1
+  2001:db8::1
+192.0.2.8/31
+
+example.com
+`
+	want := []string{"192.0.2.1", "2001:db8::1", "192.0.2.8", "192.0.2.9", "example.com"}
+
+	got, err := readTargets(strings.NewReader(input), 256)
+	if err != nil {
+		t.Fatalf("readTargets() error = %v", err)
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("readTargets() = %v, want %v", got, want)
+	}
+
+	if _, err := readTargets(strings.NewReader("192.0.2.1\nnot a target\n"), 256); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("readTargets() error = %v, want line 2 error", err)
+	}
+}
+
+func Test_rangeMatrix(t *testing.T) {
+	zen := &dnsbl.ListItem{Address: "zen.spamhaus.org"}
+	spamcop := &dnsbl.ListItem{Address: "bl.spamcop.net"}
+	clean := &dnsbl.ListItem{Address: "clean.example.com"}
+	batch := &dnsbl.BatchReport{Reports: []*dnsbl.Report{
+		{Target: "192.0.2.0", Results: []*dnsbl.Result{{List: zen}, {List: spamcop}, {List: clean}}},
+		{Target: "192.0.2.1", Results: []*dnsbl.Result{{List: zen, Status: dnsbl.StatusHit}, {List: spamcop}, {List: clean}}},
+		{Target: "192.0.2.2", Results: []*dnsbl.Result{{List: zen, Status: dnsbl.StatusHit}, {List: spamcop, Status: dnsbl.StatusHit}, {List: clean}}},
+	}}
+
+	lists, rows := rangeMatrix(batch)
+
+	if strings.Join(lists, " ") != "zen.spamhaus.org bl.spamcop.net" {
+		t.Errorf("rangeMatrix() lists = %v", lists)
+	}
+	if len(rows) != 2 || rows[0].Address != "192.0.2.1" || rows[1].Address != "192.0.2.2" {
+		t.Fatalf("rangeMatrix() rows = %+v", rows)
+	}
+	if strings.Join(rows[1].Listed, " ") != "zen.spamhaus.org bl.spamcop.net" {
+		t.Errorf("rangeMatrix() rows[1].Listed = %v", rows[1].Listed)
+	}
+}
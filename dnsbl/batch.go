@@ -0,0 +1,153 @@
+package dnsbl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// BatchReport holds the reports of checking several targets
+type BatchReport struct {
+	// Reports holds one report per target, in the same order as the targets
+	Reports []*Report
+	// Time is when the check started
+	Time time.Time
+	// Duration is how long checking all targets took
+	Duration time.Duration
+	// Whitelist is true if whitelists were checked instead of blacklists
+	Whitelist bool
+
+	// Targets is the number of checked targets
+	Targets int
+	// Listed is the number of targets with at least one hit
+	Listed int
+
+	Checks    int
+	Hits      int
+	Misses    int
+	Timeouts  int
+	Failures  int
+	Blocked   int
+	Unhealthy int
+	ServFails int
+	Refused   int
+
+	// MaxScore is the highest score of all targets
+	MaxScore float64
+	// MaxHits is the highest number of hits of all targets
+	MaxHits int
+}
+
+// CheckBatch checks every target in `targets` against all blacklists, or
+// whitelists if `whitelist` is true. A target is either an IPv4 address, an
+// IPv6 address or a domain. All targets share the same workers and each
+// list's health is only checked once.
+func (c *Checker) CheckBatch(whitelist bool, targets []string) *BatchReport {
+	return c.CheckBatchContext(context.Background(), whitelist, targets)
+}
+
+// CheckBatchContext is like CheckBatch. Queries that are outstanding when `ctx` is done are aborted.
+func (c *Checker) CheckBatchContext(ctx context.Context, whitelist bool, targets []string) *BatchReport {
+	checks := make([]check, 0, len(targets))
+	for _, target := range targets {
+		ip := net.ParseIP(target)
+		switch {
+		case ip == nil:
+			checks = append(checks, c.checkDomain(whitelist, target))
+		case ip.To4() != nil:
+			// IPv4-mapped IPv6 addresses are checked in their IPv4 form
+			checks = append(checks, c.checkIP4(whitelist, ip.To4().String()))
+		default:
+			checks = append(checks, c.checkIP6(whitelist, target))
+		}
+	}
+
+	batch := &BatchReport{Time: time.Now(), Whitelist: whitelist}
+	batch.Reports = c.run(ctx, checks)
+	batch.Duration = time.Since(batch.Time)
+
+	for _, r := range batch.Reports {
+		batch.Targets++
+		if r.Hits > 0 {
+			batch.Listed++
+		}
+		batch.Checks += r.Checks
+		batch.Hits += r.Hits
+		batch.Misses += r.Misses
+		batch.Timeouts += r.Timeouts
+		batch.Failures += r.Failures
+		batch.Blocked += r.Blocked
+		batch.Unhealthy += r.Unhealthy
+		batch.ServFails += r.ServFails
+		batch.Refused += r.Refused
+		if r.Score > batch.MaxScore {
+			batch.MaxScore = r.Score
+		}
+		if r.Hits > batch.MaxHits {
+			batch.MaxHits = r.Hits
+		}
+	}
+
+	return batch
+}
+
+// Severity returns the severity of the highest score of all targets for the `warning` and `critical` thresholds
+func (b *BatchReport) Severity(warning, critical float64) Severity {
+	return ThresholdSeverity(b.MaxScore, warning, critical)
+}
+
+// CheckRange checks every address in the IPv4 CIDR range `cidr` against all
+// blacklists, or whitelists if `whitelist` is true. It returns an error if
+// the range contains more than `max` addresses.
+func (c *Checker) CheckRange(whitelist bool, cidr string, max int) (*BatchReport, error) {
+	return c.CheckRangeContext(context.Background(), whitelist, cidr, max)
+}
+
+// CheckRangeContext is like CheckRange. Queries that are outstanding when `ctx` is done are aborted.
+func (c *Checker) CheckRangeContext(ctx context.Context, whitelist bool, cidr string, max int) (*BatchReport, error) {
+	ips, err := ExpandCIDR(cidr, max)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.CheckBatchContext(ctx, whitelist, ips), nil
+}
+
+// ExpandCIDR returns every IPv4 address in the CIDR range `cidr`. It returns an
+// error if `cidr` is not an IPv4 range or contains more than `max` addresses.
+func ExpandCIDR(cidr string, max int) ([]string, error) {
+	ip, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	if ip.To4() == nil {
+		return nil, fmt.Errorf("%v is not an IPv4 range", cidr)
+	}
+
+	ones, bits := ipNet.Mask.Size()
+	if bits-ones >= 31 || 1<<uint(bits-ones) > max {
+		return nil, fmt.Errorf("%v contains more than %v addresses", cidr, max)
+	}
+
+	ips := make([]string, 0, 1<<uint(bits-ones))
+	for ip := ipNet.IP.To4(); ipNet.Contains(ip); ip = nextIP(ip) {
+		ips = append(ips, ip.String())
+	}
+
+	return ips, nil
+}
+
+// nextIP returns the address following `ip`
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+
+	return next
+}
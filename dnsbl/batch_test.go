@@ -0,0 +1,76 @@
+package dnsbl
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+// countingResolver counts the queries sent to a fakeResolver
+type countingResolver struct {
+	*fakeResolver
+	queries int32
+}
+
+func (r *countingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	atomic.AddInt32(&r.queries, 1)
+	return r.fakeResolver.LookupHost(ctx, host)
+}
+
+func TestChecker_CheckBatch(t *testing.T) {
+	fake := newFakeList("bl.example.com")
+	fake.hosts["1.2.0.192.bl.example.com"] = []string{"127.0.0.2"}
+	r := &countingResolver{fakeResolver: fake}
+
+	c := NewChecker([]*ListItem{{Address: "bl.example.com", IP4: true, Blacklist: true}})
+	c.Resolver = r
+	batch := c.CheckBatch(false, []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"})
+
+	if batch.Targets != 3 || batch.Listed != 1 || batch.Checks != 3 || batch.Hits != 1 || batch.Misses != 2 {
+		t.Errorf("CheckBatch() counters = %+v", batch)
+	}
+	if batch.Reports[0].Target != "192.0.2.1" || batch.Reports[0].Hits != 1 {
+		t.Errorf("CheckBatch() Reports[0] = %+v", batch.Reports[0])
+	}
+	// 2 health check queries, then 1 query per target
+	if r.queries != 5 {
+		t.Errorf("CheckBatch() sent %v queries, want 5", r.queries)
+	}
+
+	// ::ffff:c000:201 is 192.0.2.1
+	batch = c.CheckBatch(false, []string{"::ffff:c000:201"})
+	if report := batch.Reports[0]; report.Target != "192.0.2.1" || report.Hits != 1 {
+		t.Errorf("CheckBatch() of IPv4-mapped address = %+v", report)
+	}
+}
+
+func TestExpandCIDR(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidr    string
+		want    []string
+		wantErr bool
+	}{
+		{"single address", "192.0.2.1/32", []string{"192.0.2.1"}, false},
+		{"range", "192.0.2.5/30", []string{"192.0.2.4", "192.0.2.5", "192.0.2.6", "192.0.2.7"}, false},
+		{"too large", "192.0.2.0/24", nil, true},
+		{"IPv6", "2001:db8::/126", nil, true},
+		{"invalid", "192.0.2.1", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandCIDR(tt.cidr, 16)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandCIDR() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ExpandCIDR() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ExpandCIDR()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package dnsbl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultThreads is the number of concurrent checks used when Checker.Threads is not set
+	DefaultThreads = 10
+	// DefaultTimeout is how long NewChecker lets a single query take
+	DefaultTimeout = 3 * time.Second
+	// DefaultRetries is how many times NewChecker repeats a query that timed out
+	DefaultRetries = 1
+)
+
+// Status is the outcome of checking a target against a single list
+type Status int
+
+const (
+	// StatusMiss means the target is not listed
+	StatusMiss Status = iota
+	// StatusHit means the target is listed
+	StatusHit
+	// StatusTimeout means the list did not respond in time
+	StatusTimeout
+	// StatusFailure means the lookup failed for any other reason
+	StatusFailure
+	// StatusBlocked means the list refused to answer the query, usually
+	// because it came from a public resolver or exceeded a query quota
+	StatusBlocked
+	// StatusUnhealthy means the list failed its health check and was not queried
+	StatusUnhealthy
+	// StatusServFail means the DNS server answered with SERVFAIL, usually
+	// because the list's nameservers could not be reached
+	StatusServFail
+	// StatusRefused means the DNS server answered with REFUSED
+	StatusRefused
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusMiss:
+		return "MISS"
+	case StatusHit:
+		return "HIT"
+	case StatusTimeout:
+		return "TIMEOUT"
+	case StatusFailure:
+		return "FAILURE"
+	case StatusBlocked:
+		return "BLOCKED"
+	case StatusUnhealthy:
+		return "UNHEALTHY"
+	case StatusServFail:
+		return "SERVFAIL"
+	case StatusRefused:
+		return "REFUSED"
+	}
+
+	return "UNKNOWN"
+}
+
+// Result is the outcome of checking a target against a single list
+type Result struct {
+	// List is the list that was queried
+	List *ListItem
+	// Status is the outcome of the check
+	Status Status
+	// ReturnCodes are the decoded answers of a list that returned a HIT
+	ReturnCodes []ReturnCode
+	// TXT are the TXT records of a list that returned a HIT. They usually
+	// contain the listing reason and a delisting URL.
+	TXT []string
+	// Err is the error that caused a timeout, failure, SERVFAIL or REFUSED
+	Err error
+	// Duration is how long the check took, including the health check
+	Duration time.Duration
+}
+
+// Report holds the results of checking a single target against all lists
+type Report struct {
+	// Target is the IP address or domain that was checked
+	Target string
+	// Results holds one result per list, in the same order as the checked lists
+	Results []*Result
+	// Time is when the check started
+	Time time.Time
+	// Duration is how long checking all lists took
+	Duration time.Duration
+	// Whitelist is true if whitelists were checked instead of blacklists
+	Whitelist bool
+
+	Checks    int
+	Hits      int
+	Misses    int
+	Timeouts  int
+	Failures  int
+	Blocked   int
+	Unhealthy int
+	ServFails int
+	Refused   int
+
+	// Score is the sum of the weights of all lists with a hit
+	Score float64
+}
+
+// Checker checks targets against a set of lists
+type Checker struct {
+	// Lists are the lists to check against
+	Lists []*ListItem
+	// Threads is the number of concurrent checks
+	Threads int
+	// Resolver is used for all DNS queries
+	Resolver Resolver
+	// HealthCache is used to skip health checks of lists that were recently healthy. Optional.
+	HealthCache *HealthCache
+	// Timeout is how long a single query may take. 0 leaves it to the Resolver.
+	Timeout time.Duration
+	// Retries is how many times a query that timed out or failed temporarily is repeated
+	Retries int
+	// RateLimit is the largest number of queries per second across all lists. 0 disables the limit.
+	RateLimit float64
+	// OperatorRateLimit is the largest number of queries per second to the lists of a
+	// single operator, e.g. all *.sorbs.net lists. 0 disables the limit.
+	OperatorRateLimit float64
+
+	healthMu sync.Mutex
+	health   map[string]*healthResult
+
+	rateMu           sync.Mutex
+	rateLimiter      *rateLimiter
+	operatorLimiters map[string]*rateLimiter
+}
+
+// NewChecker returns a Checker that uses `lists`
+func NewChecker(lists []*ListItem) *Checker {
+	return &Checker{
+		Lists:    lists,
+		Threads:  DefaultThreads,
+		Resolver: net.DefaultResolver,
+		Timeout:  DefaultTimeout,
+		Retries:  DefaultRetries,
+	}
+}
+
+// resolver returns the Resolver with the Checker's rate limits, timeout and retries applied
+func (c *Checker) resolver() Resolver {
+	limited := c.RateLimit > 0 || c.OperatorRateLimit > 0
+	if c.Timeout <= 0 && c.Retries <= 0 && !limited {
+		return c.Resolver
+	}
+
+	r := &retryResolver{r: c.Resolver, timeout: c.Timeout, retries: c.Retries}
+	if limited {
+		r.wait = c.waitRate
+	}
+
+	return r
+}
+
+type lookupFunc func(context.Context, string, *ListItem) (*listing, error)
+
+type workUnit struct {
+	// address is the IP address or domain being checked
+	address string
+	// result is filled in by the worker
+	result *Result
+	// whitelist is true if whitelists are being checked
+	whitelist bool
+	// report is the report `result` belongs to
+	report *Report
+	// pending is the number of results of `report` that are not filled in yet
+	pending *int32
+
+	ctx        context.Context
+	lookupFunc lookupFunc
+}
+
+// CheckIP4 checks `ip` against all IPv4 blacklists, or whitelists if `whitelist` is true
+func (c *Checker) CheckIP4(whitelist bool, ip string) *Report {
+	return c.CheckIP4Context(context.Background(), whitelist, ip)
+}
+
+// CheckIP4Context is like CheckIP4. Queries that are outstanding when `ctx` is done are aborted.
+func (c *Checker) CheckIP4Context(ctx context.Context, whitelist bool, ip string) *Report {
+	return c.run(ctx, []check{c.checkIP4(whitelist, ip)})[0]
+}
+
+// CheckIP6 checks `ip` against all IPv6 blacklists, or whitelists if `whitelist` is true
+func (c *Checker) CheckIP6(whitelist bool, ip string) *Report {
+	return c.CheckIP6Context(context.Background(), whitelist, ip)
+}
+
+// CheckIP6Context is like CheckIP6. Queries that are outstanding when `ctx` is done are aborted.
+func (c *Checker) CheckIP6Context(ctx context.Context, whitelist bool, ip string) *Report {
+	return c.run(ctx, []check{c.checkIP6(whitelist, ip)})[0]
+}
+
+// CheckDomain checks `domain` against all domain blacklists, or whitelists if `whitelist` is true
+func (c *Checker) CheckDomain(whitelist bool, domain string) *Report {
+	return c.CheckDomainContext(context.Background(), whitelist, domain)
+}
+
+// CheckDomainContext is like CheckDomain. Queries that are outstanding when `ctx` is done are aborted.
+func (c *Checker) CheckDomainContext(ctx context.Context, whitelist bool, domain string) *Report {
+	return c.run(ctx, []check{c.checkDomain(whitelist, domain)})[0]
+}
+
+func (c *Checker) checkIP4(whitelist bool, ip string) check {
+	lists := []*ListItem{}
+	for _, v := range c.Lists {
+		if v.IP4 && v.checks(whitelist) {
+			lists = append(lists, v)
+		}
+	}
+
+	return check{target: ip, lists: lists, whitelist: whitelist, lookupFunc: c.lookupIP4}
+}
+
+func (c *Checker) checkIP6(whitelist bool, ip string) check {
+	lists := []*ListItem{}
+	for _, v := range c.Lists {
+		if v.IP6 && v.checks(whitelist) {
+			lists = append(lists, v)
+		}
+	}
+
+	return check{target: ip, lists: lists, whitelist: whitelist, lookupFunc: c.lookupIP6}
+}
+
+func (c *Checker) checkDomain(whitelist bool, domain string) check {
+	lists := []*ListItem{}
+	for _, v := range c.Lists {
+		if v.Domain && v.checks(whitelist) {
+			lists = append(lists, v)
+		}
+	}
+
+	return check{target: domain, lists: lists, whitelist: whitelist, lookupFunc: c.lookupDomain}
+}
+
+func worker(wg *sync.WaitGroup, ch chan *workUnit) {
+	defer wg.Done()
+
+	for wu := range ch {
+		start := time.Now()
+		// queries are not started once the check has been aborted
+		var l *listing
+		var blocked *blockedError
+		err := wu.ctx.Err()
+		if err == nil {
+			l, err = wu.lookupFunc(wu.ctx, wu.address, wu.result.List)
+		}
+		wu.result.Duration = time.Since(start)
+		wu.result.Err = err
+		if errors.Is(err, ErrQueryBlocked) {
+			wu.result.Status = StatusBlocked
+			wu.result.Err = ErrQueryBlocked
+			// the codes explain why, e.g. that the query came through a public resolver
+			if errors.As(err, &blocked) {
+				wu.result.ReturnCodes = decodeReturnCodes(wu.result.List, blocked.codes)
+			}
+		} else if err == ErrRBLFail || err == ErrRBLPositiveFail || err == ErrRBLNegativeFail {
+			wu.result.Status = StatusUnhealthy
+		} else if err != nil {
+			wu.result.Status = classifyError(err)
+			if wu.result.Status == StatusMiss {
+				wu.result.Err = nil
+			}
+		} else if l != nil {
+			wu.result.ReturnCodes = decodeReturnCodes(wu.result.List, l.codes)
+			wu.result.TXT = l.txt
+			// answers of combined lists can mean the opposite of what is being checked
+			if wu.result.List.isListed(l.codes, wu.whitelist) {
+				wu.result.Status = StatusHit
+			}
+		}
+
+		// the report is done once its last result is filled in, other reports may still be running
+		if atomic.AddInt32(wu.pending, -1) == 0 {
+			wu.report.Duration = time.Since(wu.report.Time)
+		}
+	}
+}
+
+// check is a single target to be checked against `lists`
+type check struct {
+	target     string
+	lists      []*ListItem
+	whitelist  bool
+	lookupFunc lookupFunc
+}
+
+// run performs all `checks` using a single pool of workers and returns one report per check.
+// Once `ctx` is done, outstanding queries are aborted and the remaining checks fail.
+func (c *Checker) run(ctx context.Context, checks []check) []*Report {
+	threads := c.Threads
+	if threads < 1 {
+		threads = DefaultThreads
+	}
+
+	wg := &sync.WaitGroup{}
+	workChan := make(chan *workUnit)
+
+	wg.Add(threads)
+	for i := 1; i <= threads; i++ {
+		go worker(wg, workChan)
+	}
+
+	reports := make([]*Report, 0, len(checks))
+	for _, chk := range checks {
+		report := &Report{
+			Target:    chk.target,
+			Results:   make([]*Result, 0, len(chk.lists)),
+			Time:      time.Now(),
+			Whitelist: chk.whitelist,
+		}
+		reports = append(reports, report)
+		pending := int32(len(chk.lists))
+
+		for _, listItem := range chk.lists {
+			res := &Result{List: listItem}
+			report.Results = append(report.Results, res)
+
+			workChan <- &workUnit{
+				address:    chk.target,
+				result:     res,
+				whitelist:  chk.whitelist,
+				report:     report,
+				pending:    &pending,
+				ctx:        ctx,
+				lookupFunc: chk.lookupFunc,
+			}
+		}
+	}
+
+	close(workChan)
+	wg.Wait()
+
+	for _, report := range reports {
+		report.count()
+	}
+
+	return reports
+}
+
+// count sets the report counters from its results
+func (r *Report) count() {
+	for _, res := range r.Results {
+		r.Checks++
+		switch res.Status {
+		case StatusHit:
+			r.Hits++
+			r.Score += res.List.EffectiveWeight()
+		case StatusMiss:
+			r.Misses++
+		case StatusTimeout:
+			r.Timeouts++
+		case StatusFailure:
+			r.Failures++
+		case StatusBlocked:
+			r.Blocked++
+		case StatusUnhealthy:
+			r.Unhealthy++
+		case StatusServFail:
+			r.ServFails++
+		case StatusRefused:
+			r.Refused++
+		}
+	}
+}
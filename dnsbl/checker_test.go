@@ -0,0 +1,165 @@
+package dnsbl
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestChecker_run(t *testing.T) {
+	lists := []*ListItem{
+		{Address: "hit.example.com", ReturnCodes: map[string]string{"127.0.0.2": "spam"}},
+		{Address: "miss.example.com"},
+		{Address: "timeout.example.com"},
+		{Address: "failure.example.com"},
+		{Address: "blocked.example.com"},
+		{Address: "unhealthy.example.com"},
+		{Address: "servfail.example.com"},
+		{Address: "refused.example.com"},
+	}
+
+	lookup := func(ctx context.Context, address string, list *ListItem) (*listing, error) {
+		switch list.Address {
+		case "hit.example.com":
+			return &listing{codes: []string{"127.0.0.2"}, txt: []string{"https://hit.example.com/lookup"}}, nil
+		case "miss.example.com":
+			return nil, &net.DNSError{Err: "no such host", Name: "2.0.0.127.miss.example.com", IsNotFound: true}
+		case "timeout.example.com":
+			return nil, &net.DNSError{Err: "i/o timeout", Name: "2.0.0.127.timeout.example.com", IsTimeout: true}
+		case "blocked.example.com":
+			return nil, ErrQueryBlocked
+		case "unhealthy.example.com":
+			return nil, ErrRBLPositiveFail
+		case "servfail.example.com":
+			return nil, &net.DNSError{Err: "server misbehaving", Name: "2.0.0.127.servfail.example.com", IsTemporary: true}
+		case "refused.example.com":
+			return nil, &ResponseError{Name: "2.0.0.127.refused.example.com", Rcode: RcodeRefused}
+		}
+		return nil, ErrWrongResponse
+	}
+
+	c := NewChecker(lists)
+	c.Threads = 2
+	report := c.run(context.Background(), []check{{target: "127.0.0.2", lists: lists, lookupFunc: lookup}})[0]
+
+	if report.Checks != 8 || report.Hits != 1 || report.Misses != 1 || report.Timeouts != 1 || report.Failures != 1 || report.Blocked != 1 ||
+		report.Unhealthy != 1 || report.ServFails != 1 || report.Refused != 1 {
+		t.Errorf("run() counters = %+v", report)
+	}
+
+	want := []Status{StatusHit, StatusMiss, StatusTimeout, StatusFailure, StatusBlocked, StatusUnhealthy, StatusServFail, StatusRefused}
+	for i, res := range report.Results {
+		if res.List != lists[i] {
+			t.Errorf("Results[%d].List = %v, want %v", i, res.List.Address, lists[i].Address)
+		}
+		if res.Status != want[i] {
+			t.Errorf("Results[%d].Status = %v, want %v", i, res.Status, want[i])
+		}
+	}
+
+	if rc := report.Results[0].ReturnCodes; len(rc) != 1 || rc[0] != (ReturnCode{"127.0.0.2", "spam"}) {
+		t.Errorf("Results[0].ReturnCodes = %v", rc)
+	}
+	if txt := report.Results[0].TXT; len(txt) != 1 || txt[0] != "https://hit.example.com/lookup" {
+		t.Errorf("Results[0].TXT = %v", txt)
+	}
+}
+
+func TestChecker_combinedLists(t *testing.T) {
+	r := newFakeList("combined.example.com")
+	r.hosts["1.2.0.192.combined.example.com"] = []string{"127.0.0.2"}
+	r.hosts["2.2.0.192.combined.example.com"] = []string{"127.0.0.1"}
+	r.hosts["3.2.0.192.combined.example.com"] = []string{"127.0.0.3"}
+
+	c := NewChecker([]*ListItem{{
+		Address:        "combined.example.com",
+		IP4:            true,
+		Combined:       true,
+		BlacklistCodes: []string{"127.0.0.2"},
+		WhitelistCodes: []string{"127.0.0.1"},
+	}})
+	c.Resolver = r
+
+	tests := []struct {
+		ip        string
+		whitelist bool
+		want      Status
+	}{
+		{"192.0.2.1", false, StatusHit},
+		{"192.0.2.1", true, StatusMiss},
+		{"192.0.2.2", false, StatusMiss},
+		{"192.0.2.2", true, StatusHit},
+		{"192.0.2.3", false, StatusMiss},
+		{"192.0.2.3", true, StatusMiss},
+	}
+	for _, tt := range tests {
+		report := c.CheckIP4(tt.whitelist, tt.ip)
+		if len(report.Results) != 1 || report.Results[0].Status != tt.want {
+			t.Errorf("CheckIP4(%v, %v) = %v, want %v", tt.whitelist, tt.ip, report.Results[0].Status, tt.want)
+		}
+	}
+}
+
+func TestChecker_CheckIP4Context(t *testing.T) {
+	r := newFakeList("bl.example.com")
+	r.hosts["1.2.0.192.bl.example.com"] = []string{"127.0.0.2"}
+	c := NewChecker([]*ListItem{{Address: "bl.example.com", IP4: true, Blacklist: true}})
+	c.Resolver = r
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	report := c.CheckIP4Context(ctx, false, "192.0.2.1")
+	if report.Timeouts != 1 || report.Results[0].Err != context.DeadlineExceeded {
+		t.Errorf("CheckIP4Context() = %+v, want timeout", report.Results[0])
+	}
+
+	// the aborted health check is not reused
+	if report := c.CheckIP4(false, "192.0.2.1"); report.Hits != 1 {
+		t.Errorf("CheckIP4() = %+v, want hit", report.Results[0])
+	}
+}
+
+func TestChecker_run_durations(t *testing.T) {
+	lists := []*ListItem{{Address: "bl.example.com"}}
+	lookup := func(ctx context.Context, address string, list *ListItem) (*listing, error) {
+		if address == "192.0.2.2" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: address, IsNotFound: true}
+	}
+
+	c := NewChecker(lists)
+	c.Threads = 2
+	reports := c.run(context.Background(), []check{
+		{target: "192.0.2.2", lists: lists, lookupFunc: lookup},
+		{target: "192.0.2.1", lists: lists, lookupFunc: lookup},
+	})
+
+	// a fast target is not held up by a slow one
+	if slow := reports[0].Duration; slow < 200*time.Millisecond {
+		t.Errorf("run() slow Duration = %v, want at least 200ms", slow)
+	}
+	if fast := reports[1].Duration; fast >= 100*time.Millisecond {
+		t.Errorf("run() fast Duration = %v, want less than 100ms", fast)
+	}
+}
+
+func TestChecker_CheckIP4_blockedHealthCheck(t *testing.T) {
+	// public resolvers are refused with the same code for every query, including the health check
+	r := newFakeList("bl.example.com")
+	r.hosts["2.0.0.127.bl.example.com"] = []string{"127.255.255.254"}
+	r.hosts["1.0.0.127.bl.example.com"] = []string{"127.255.255.254"}
+	c := NewChecker([]*ListItem{{Address: "bl.example.com", IP4: true, Blacklist: true,
+		BlockedCodes: map[string]string{"127.255.255.254": "Query via public/open resolver"}}})
+	c.Resolver = r
+
+	res := c.CheckIP4(false, "192.0.2.1").Results[0]
+	if res.Status != StatusBlocked || res.Err != ErrQueryBlocked {
+		t.Errorf("CheckIP4() = %v, %v, want %v", res.Status, res.Err, StatusBlocked)
+	}
+	if rc := res.ReturnCodes; len(rc) != 1 || rc[0] != (ReturnCode{"127.255.255.254", "Query via public/open resolver"}) {
+		t.Errorf("CheckIP4() ReturnCodes = %v", rc)
+	}
+}
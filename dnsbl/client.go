@@ -0,0 +1,634 @@
+package dnsbl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QType is the type of a DNS query or record
+type QType uint16
+
+// Query and record types used by Client
+const (
+	TypeA     QType = 1
+	TypeCNAME QType = 5
+	TypeTXT   QType = 16
+	TypeAAAA  QType = 28
+
+	typeOPT QType = 41
+)
+
+func (t QType) String() string {
+	switch t {
+	case TypeA:
+		return "A"
+	case TypeCNAME:
+		return "CNAME"
+	case TypeTXT:
+		return "TXT"
+	case TypeAAAA:
+		return "AAAA"
+	}
+
+	return fmt.Sprintf("TYPE%d", uint16(t))
+}
+
+// Rcode is the response code of a DNS answer
+type Rcode int
+
+// Response codes, see https://tools.ietf.org/html/rfc1035#section-4.1.1
+const (
+	RcodeSuccess        Rcode = 0
+	RcodeFormatError    Rcode = 1
+	RcodeServerFailure  Rcode = 2
+	RcodeNameError      Rcode = 3
+	RcodeNotImplemented Rcode = 4
+	RcodeRefused        Rcode = 5
+)
+
+func (r Rcode) String() string {
+	switch r {
+	case RcodeSuccess:
+		return "NOERROR"
+	case RcodeFormatError:
+		return "FORMERR"
+	case RcodeServerFailure:
+		return "SERVFAIL"
+	case RcodeNameError:
+		return "NXDOMAIN"
+	case RcodeNotImplemented:
+		return "NOTIMP"
+	case RcodeRefused:
+		return "REFUSED"
+	}
+
+	return fmt.Sprintf("RCODE%d", int(r))
+}
+
+// Record is a single resource record from the answer section of a response
+type Record struct {
+	Name string
+	Type QType
+	TTL  uint32
+	// Data is the address of A and AAAA records, the target of CNAME
+	// records and the concatenated strings of TXT records
+	Data string
+}
+
+// Response is the answer of a DNS server to a single query
+type Response struct {
+	Rcode   Rcode
+	Answers []Record
+}
+
+// ResponseError is returned by Client lookups that did not get any records. Rcode
+// is RcodeSuccess if the name exists, but has no records of the queried type.
+type ResponseError struct {
+	Name   string
+	Server string
+	Rcode  Rcode
+}
+
+func (e *ResponseError) Error() string {
+	if e.Rcode == RcodeSuccess {
+		return "lookup " + e.Name + " on " + e.Server + ": no answer"
+	}
+
+	return "lookup " + e.Name + " on " + e.Server + ": " + e.Rcode.String()
+}
+
+// udpSize is the largest UDP answer advertised with EDNS(0). Larger answers are
+// truncated and repeated over TCP.
+const udpSize = 1232
+
+var errMalformedResponse = errors.New("malformed DNS response")
+
+// Transport is how a Client sends queries to its server
+type Transport int
+
+const (
+	// TransportUDP sends queries over UDP and repeats them over TCP if the answer was truncated
+	TransportUDP Transport = iota
+	// TransportTLS sends queries over DNS-over-TLS, see https://tools.ietf.org/html/rfc7858
+	TransportTLS
+	// TransportHTTPS sends queries over DNS-over-HTTPS, see https://tools.ietf.org/html/rfc8484
+	TransportHTTPS
+)
+
+// Client sends queries directly to a recursive DNS server. Unlike the system
+// resolver, it is not affected by /etc/hosts, search domains or OS caches, and
+// it exposes the rcode and TTL of every answer. Client implements Resolver.
+type Client struct {
+	// Server is the recursive DNS server in host:port form, or the URL of a
+	// DNS-over-HTTPS server
+	Server string
+	// Transport is how queries are sent to Server
+	Transport Transport
+	// TLSConfig is used by TransportTLS. The server name is taken from Server if it's not set.
+	TLSConfig *tls.Config
+	// HTTPClient is used by TransportHTTPS. http.DefaultClient is used if it's nil.
+	HTTPClient *http.Client
+
+	mu sync.Mutex
+	// idle are open DNS-over-TLS connections that are not used by a query
+	idle []net.Conn
+}
+
+// NewClient returns a Client that sends all queries to `server`. `server` is
+// in host:port form, where port 53 is used if it's omitted, a tls://host:port
+// URL for DNS-over-TLS, where port 853 is used if it's omitted, or an
+// https:// URL for DNS-over-HTTPS.
+func NewClient(server string) *Client {
+	switch {
+	case strings.HasPrefix(server, "https://"):
+		return &Client{Server: server, Transport: TransportHTTPS}
+	case strings.HasPrefix(server, "tls://"):
+		return &Client{Server: withDefaultPort(strings.TrimPrefix(server, "tls://"), "853"), Transport: TransportTLS}
+	}
+
+	return &Client{Server: withDefaultPort(server, "53")}
+}
+
+// withDefaultPort returns `server` in host:port form, using `port` if it has none
+func withDefaultPort(server, port string) string {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(strings.Trim(server, "[]"), port)
+	}
+
+	return server
+}
+
+// LookupHost returns the IPv4 addresses of `host`. IPv6 addresses are only
+// queried if `host` has no IPv4 addresses, as DNSBLs answer with A records.
+func (c *Client) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, err := c.lookup(ctx, host, TypeA)
+	if rerr, ok := err.(*ResponseError); ok && rerr.Rcode == RcodeSuccess {
+		return c.lookup(ctx, host, TypeAAAA)
+	}
+
+	return addrs, err
+}
+
+// LookupTXT returns the TXT records of `name`
+func (c *Client) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return c.lookup(ctx, name, TypeTXT)
+}
+
+// lookup returns the data of all records of type `qtype` in the answer to `name`.
+// It returns a *ResponseError if there are none.
+func (c *Client) lookup(ctx context.Context, name string, qtype QType) ([]string, error) {
+	resp, err := c.Query(ctx, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []string{}
+	for _, rr := range resp.Answers {
+		if rr.Type == qtype {
+			data = append(data, rr.Data)
+		}
+	}
+	if resp.Rcode != RcodeSuccess || len(data) == 0 {
+		return nil, &ResponseError{Name: name, Server: c.Server, Rcode: resp.Rcode}
+	}
+
+	return data, nil
+}
+
+// Query sends a query for `name` and `qtype` and returns the answer, whatever its
+// rcode. The query is aborted when `ctx` is done, or after DefaultTimeout if
+// `ctx` has no deadline.
+func (c *Client) Query(ctx context.Context, name string, qtype QType) (*Response, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
+	id := uint16(rand.Intn(1 << 16))
+	if c.Transport == TransportHTTPS {
+		// DNS-over-HTTPS uses ID 0, so answers can be cached by HTTP caches
+		id = 0
+	}
+	query, err := packQuery(id, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg []byte
+	switch c.Transport {
+	case TransportHTTPS:
+		msg, err = c.exchangeHTTPS(ctx, query)
+	case TransportTLS:
+		msg, err = c.exchange(ctx, "tls", query)
+	default:
+		msg, err = c.exchange(ctx, "udp", query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp, truncated, err := unpackResponse(msg, id)
+	if err == nil && truncated && c.Transport == TransportUDP {
+		if msg, err = c.exchange(ctx, "tcp", query); err == nil {
+			resp, _, err = unpackResponse(msg, id)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// exchange sends `query` to the server over `network`, which is udp, tcp or tls, and returns the answer
+func (c *Client) exchange(ctx context.Context, network string, query []byte) ([]byte, error) {
+	if network == "tls" {
+		return c.exchangeTLS(ctx, query)
+	}
+
+	d := net.Dialer{}
+	conn, err := d.DialContext(ctx, network, c.Server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if network == "udp" {
+		return exchangeContext(ctx, conn, query, exchangeUDP)
+	}
+
+	return exchangeContext(ctx, conn, query, exchangeTCP)
+}
+
+// exchangeTLS sends `query` over an idle DNS-over-TLS connection, or a new one if
+// there is none. Connections are kept open for later queries, as recommended by
+// RFC 7858 section 3.4, to avoid a TCP and TLS handshake for every query.
+func (c *Client) exchangeTLS(ctx context.Context, query []byte) ([]byte, error) {
+	conn := c.idleConn()
+	if conn != nil {
+		msg, err := exchangeContext(ctx, conn, query, exchangeTCP)
+		if err == nil {
+			c.putIdleConn(conn)
+			return msg, nil
+		}
+		conn.Close()
+		// the server may have closed the idle connection, so the query is repeated over a new one
+		if ctx.Err() != nil {
+			return nil, err
+		}
+	}
+
+	d := net.Dialer{}
+	tcpConn, err := d.DialContext(ctx, "tcp", c.Server)
+	if err != nil {
+		return nil, err
+	}
+	// the handshake happens on the first write, within the deadline of the exchange
+	conn = tls.Client(tcpConn, c.tlsConfig())
+	msg, err := exchangeContext(ctx, conn, query, exchangeTCP)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c.putIdleConn(conn)
+
+	return msg, nil
+}
+
+// idleConn returns an idle DNS-over-TLS connection, or nil if there is none
+func (c *Client) idleConn() net.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.idle) == 0 {
+		return nil
+	}
+	conn := c.idle[len(c.idle)-1]
+	c.idle = c.idle[:len(c.idle)-1]
+
+	return conn
+}
+
+// putIdleConn keeps `conn` open for later queries
+func (c *Client) putIdleConn(conn net.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.idle = append(c.idle, conn)
+}
+
+// CloseIdleConnections closes the DNS-over-TLS connections that are kept open for later queries
+func (c *Client) CloseIdleConnections() {
+	c.mu.Lock()
+	idle := c.idle
+	c.idle = nil
+	c.mu.Unlock()
+
+	for _, conn := range idle {
+		conn.Close()
+	}
+}
+
+// exchangeContext sends `query` over `conn` with `exchangeFunc` and returns the
+// answer. The exchange is aborted when `ctx` is done. The deadline of `conn` is
+// cleared afterwards, so it can be reused.
+func exchangeContext(ctx context.Context, conn net.Conn, query []byte, exchangeFunc func(net.Conn, []byte) ([]byte, error)) ([]byte, error) {
+	// the connection is closed early if `ctx` is cancelled before its deadline
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	msg, err := exchangeFunc(conn, query)
+	close(done)
+	<-stopped
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, conn.SetDeadline(time.Time{})
+}
+
+// tlsConfig returns the TLS configuration of DNS-over-TLS connections
+func (c *Client) tlsConfig() *tls.Config {
+	config := &tls.Config{}
+	if c.TLSConfig != nil {
+		config = c.TLSConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName, _, _ = net.SplitHostPort(c.Server)
+	}
+
+	return config
+}
+
+// exchangeHTTPS posts `query` to the DNS-over-HTTPS server and returns the answer
+func (c *Client) exchangeHTTPS(ctx context.Context, query []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Server, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS-over-HTTPS server %v answered with HTTP status %v", c.Server, resp.Status)
+	}
+
+	// DNS messages are never longer than 64 KiB
+	return ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16))
+}
+
+// exchangeUDP sends `query` as a single datagram and returns the answer
+func exchangeUDP(conn net.Conn, query []byte) ([]byte, error) {
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, udpSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		// answers to other queries are ignored, like spoofed or late ones
+		if n >= 2 && buf[0] == query[0] && buf[1] == query[1] {
+			return buf[:n], nil
+		}
+	}
+}
+
+// exchangeTCP sends `query` with a length prefix and returns the answer
+func exchangeTCP(conn net.Conn, query []byte) ([]byte, error) {
+	out := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(out, uint16(len(query)))
+	copy(out[2:], query)
+	if _, err := conn.Write(out); err != nil {
+		return nil, err
+	}
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// packQuery returns a recursive query for `name` and `qtype` with an EDNS(0) OPT record
+func packQuery(id uint16, name string, qtype QType) ([]byte, error) {
+	msg := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	// recursion desired
+	binary.BigEndian.PutUint16(msg[2:], 0x0100)
+	// one question and one additional record
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	binary.BigEndian.PutUint16(msg[10:], 1)
+
+	msg, err := packName(msg, name)
+	if err != nil {
+		return nil, err
+	}
+	msg = appendUint16(msg, uint16(qtype))
+	// class IN
+	msg = appendUint16(msg, 1)
+
+	// OPT record: root name, type, UDP size as class, TTL and no data
+	msg = append(msg, 0)
+	msg = appendUint16(msg, uint16(typeOPT))
+	msg = appendUint16(msg, udpSize)
+	msg = append(msg, 0, 0, 0, 0, 0, 0)
+
+	return msg, nil
+}
+
+// packName appends `name` in wire format to `msg`
+func packName(msg []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > 253 {
+		return nil, fmt.Errorf("DNS name too long: %v", name)
+	}
+
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("invalid DNS name: %v", name)
+			}
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+	}
+
+	return append(msg, 0), nil
+}
+
+func appendUint16(msg []byte, v uint16) []byte {
+	return append(msg, byte(v>>8), byte(v))
+}
+
+// unpackResponse returns the rcode and answer records of `msg`, and whether it was truncated
+func unpackResponse(msg []byte, id uint16) (*Response, bool, error) {
+	if len(msg) < 12 || binary.BigEndian.Uint16(msg) != id {
+		return nil, false, errMalformedResponse
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&0x8000 == 0 {
+		return nil, false, errMalformedResponse
+	}
+	resp := &Response{Rcode: Rcode(flags & 0x000f)}
+	truncated := flags&0x0200 != 0
+
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+	off := 12
+	for i := 0; i < qdcount; i++ {
+		var err error
+		if _, off, err = unpackName(msg, off); err != nil {
+			return nil, false, err
+		}
+		off += 4
+	}
+
+	for i := 0; i < ancount; i++ {
+		rr, next, err := unpackRecord(msg, off)
+		if err != nil {
+			// a truncated answer may end in the middle of a record
+			if truncated {
+				break
+			}
+			return nil, false, err
+		}
+		off = next
+		if rr != nil {
+			resp.Answers = append(resp.Answers, *rr)
+		}
+	}
+
+	return resp, truncated, nil
+}
+
+// unpackRecord returns the resource record at `off` and the offset after it.
+// The record is nil if its type is not used by Client.
+func unpackRecord(msg []byte, off int) (*Record, int, error) {
+	name, off, err := unpackName(msg, off)
+	if err != nil {
+		return nil, 0, err
+	}
+	if off+10 > len(msg) {
+		return nil, 0, errMalformedResponse
+	}
+	rr := &Record{
+		Name: name,
+		Type: QType(binary.BigEndian.Uint16(msg[off:])),
+		TTL:  binary.BigEndian.Uint32(msg[off+4:]),
+	}
+	length := int(binary.BigEndian.Uint16(msg[off+8:]))
+	off += 10
+	end := off + length
+	if end > len(msg) {
+		return nil, 0, errMalformedResponse
+	}
+	data := msg[off:end]
+
+	switch rr.Type {
+	case TypeA:
+		if length != net.IPv4len {
+			return nil, 0, errMalformedResponse
+		}
+		rr.Data = net.IP(data).String()
+	case TypeAAAA:
+		if length != net.IPv6len {
+			return nil, 0, errMalformedResponse
+		}
+		rr.Data = net.IP(data).String()
+	case TypeCNAME:
+		if rr.Data, _, err = unpackName(msg, off); err != nil {
+			return nil, 0, err
+		}
+	case TypeTXT:
+		var txt strings.Builder
+		for i := 0; i < len(data); {
+			n := int(data[i])
+			if i+1+n > len(data) {
+				return nil, 0, errMalformedResponse
+			}
+			txt.Write(data[i+1 : i+1+n])
+			i += 1 + n
+		}
+		rr.Data = txt.String()
+	default:
+		return nil, end, nil
+	}
+
+	return rr, end, nil
+}
+
+// unpackName returns the possibly compressed name at `off` and the offset after it
+func unpackName(msg []byte, off int) (string, int, error) {
+	labels := []string{}
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errMalformedResponse
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, "."), next, nil
+		case n&0xc0 == 0xc0:
+			if off+1 >= len(msg) || jumps > 10 {
+				return "", 0, errMalformedResponse
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			jumps++
+		case n > 63 || off+1+n > len(msg):
+			return "", 0, errMalformedResponse
+		default:
+			labels = append(labels, string(msg[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package dnsbl
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer is a DNS server on localhost that answers UDP and TCP queries with `answer`
+type testServer struct {
+	udp    net.PacketConn
+	tcp    net.Listener
+	answer func(name string, qtype QType, tcp bool) (Rcode, []Record, bool)
+	// accepted is the number of accepted TCP connections
+	accepted int32
+}
+
+// newTestServer starts a testServer on a free port. It is stopped when the test ends.
+func newTestServer(t *testing.T, answer func(name string, qtype QType, tcp bool) (Rcode, []Record, bool)) *testServer {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		udp.Close()
+		t.Fatal(err)
+	}
+	s := &testServer{udp: udp, tcp: tcp, answer: answer}
+	t.Cleanup(func() {
+		udp.Close()
+		tcp.Close()
+	})
+
+	go s.serveUDP()
+	go s.serveTCP()
+
+	return s
+}
+
+func (s *testServer) addr() string {
+	return s.udp.LocalAddr().String()
+}
+
+func (s *testServer) serveUDP() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		s.udp.WriteTo(s.respond(buf[:n], false), addr)
+	}
+}
+
+func (s *testServer) serveTCP() {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		atomic.AddInt32(&s.accepted, 1)
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn answers queries on `conn` until the client closes it
+func (s *testServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return
+		}
+		query := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+		resp := s.respond(query, true)
+		if _, err := conn.Write(append(appendUint16(nil, uint16(len(resp))), resp...)); err != nil {
+			return
+		}
+	}
+}
+
+// respond returns the answer to `query`
+func (s *testServer) respond(query []byte, tcp bool) []byte {
+	name, off, _ := unpackName(query, 12)
+	qtype := QType(binary.BigEndian.Uint16(query[off:]))
+	rcode, answers, truncated := s.answer(name, qtype, tcp)
+
+	flags := uint16(0x8180) | uint16(rcode)
+	if truncated {
+		flags |= 0x0200
+	}
+	msg := append([]byte{}, query[:2]...)
+	msg = appendUint16(msg, flags)
+	msg = append(msg, 0, 1)
+	msg = appendUint16(msg, uint16(len(answers)))
+	msg = append(msg, 0, 0, 0, 0)
+	msg = append(msg, query[12:off+4]...)
+
+	for _, rr := range answers {
+		var data []byte
+		switch rr.Type {
+		case TypeA:
+			data = net.ParseIP(rr.Data).To4()
+		case TypeTXT:
+			for _, s := range strings.Split(rr.Data, "|") {
+				data = append(data, byte(len(s)))
+				data = append(data, s...)
+			}
+		}
+		// the name is compressed to a pointer to the question
+		msg = append(msg, 0xc0, 12)
+		msg = appendUint16(msg, uint16(rr.Type))
+		msg = appendUint16(msg, 1)
+		msg = append(msg, byte(rr.TTL>>24), byte(rr.TTL>>16), byte(rr.TTL>>8), byte(rr.TTL))
+		msg = appendUint16(msg, uint16(len(data)))
+		msg = append(msg, data...)
+	}
+
+	return msg
+}
+
+func TestClient(t *testing.T) {
+	s := newTestServer(t, func(name string, qtype QType, tcp bool) (Rcode, []Record, bool) {
+		switch {
+		case name == "2.0.0.127.bl.example.com" && qtype == TypeA:
+			return RcodeSuccess, []Record{{Type: TypeA, TTL: 300, Data: "127.0.0.2"}}, false
+		case name == "2.0.0.127.bl.example.com" && qtype == TypeTXT:
+			return RcodeSuccess, []Record{{Type: TypeTXT, TTL: 300, Data: "Listed|, see https://bl.example.com"}}, false
+		case name == "big.bl.example.com" && !tcp:
+			return RcodeSuccess, nil, true
+		case name == "big.bl.example.com":
+			return RcodeSuccess, []Record{{Type: TypeA, TTL: 60, Data: "127.0.0.3"}}, false
+		case name == "servfail.bl.example.com":
+			return RcodeServerFailure, nil, false
+		case name == "refused.bl.example.com":
+			return RcodeRefused, nil, false
+		}
+		return RcodeNameError, nil, false
+	})
+	c := NewClient(s.addr())
+	ctx := context.Background()
+
+	resp, err := c.Query(ctx, "2.0.0.127.bl.example.com", TypeA)
+	if err != nil || resp.Rcode != RcodeSuccess || len(resp.Answers) != 1 {
+		t.Fatalf("Query() = %+v, %v", resp, err)
+	}
+	if rr := resp.Answers[0]; rr.Name != "2.0.0.127.bl.example.com" || rr.TTL != 300 || rr.Data != "127.0.0.2" {
+		t.Errorf("Query() answer = %+v", rr)
+	}
+
+	if txt, err := c.LookupTXT(ctx, "2.0.0.127.bl.example.com"); err != nil || len(txt) != 1 || txt[0] != "Listed, see https://bl.example.com" {
+		t.Errorf("LookupTXT() = %q, %v", txt, err)
+	}
+
+	if addrs, err := c.LookupHost(ctx, "big.bl.example.com"); err != nil || len(addrs) != 1 || addrs[0] != "127.0.0.3" {
+		t.Errorf("LookupHost() of truncated answer = %v, %v, want TCP answer", addrs, err)
+	}
+
+	tests := []struct {
+		host string
+		want Status
+	}{
+		{"1.0.0.127.bl.example.com", StatusMiss},
+		{"servfail.bl.example.com", StatusServFail},
+		{"refused.bl.example.com", StatusRefused},
+	}
+	for _, tt := range tests {
+		if _, err := c.LookupHost(ctx, tt.host); classifyError(err) != tt.want {
+			t.Errorf("LookupHost(%v) error = %v, want %v", tt.host, err, tt.want)
+		}
+	}
+}
+
+// answerTestList answers queries like a healthy IPv4 list at bl.example.com
+func answerTestList(name string, qtype QType, tcp bool) (Rcode, []Record, bool) {
+	if name == "2.0.0.127.bl.example.com" && qtype == TypeA {
+		return RcodeSuccess, []Record{{Type: TypeA, TTL: 300, Data: "127.0.0.2"}}, false
+	}
+	return RcodeNameError, nil, false
+}
+
+func TestClient_https(t *testing.T) {
+	s := &testServer{answer: answerTestList}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, _ := ioutil.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/dns-message" || binary.BigEndian.Uint16(query) != 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(s.respond(query, true))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL + "/dns-query")
+	c.HTTPClient = srv.Client()
+	if c.Transport != TransportHTTPS {
+		t.Fatalf("NewClient() transport = %v, want %v", c.Transport, TransportHTTPS)
+	}
+
+	if addrs, err := c.LookupHost(context.Background(), "2.0.0.127.bl.example.com"); err != nil || len(addrs) != 1 || addrs[0] != "127.0.0.2" {
+		t.Errorf("LookupHost() = %v, %v", addrs, err)
+	}
+	if _, err := c.LookupHost(context.Background(), "1.0.0.127.bl.example.com"); !isNotFound(err) {
+		t.Errorf("LookupHost() error = %v, want not found", err)
+	}
+}
+
+func TestClient_tls(t *testing.T) {
+	// the certificate of the HTTPS test server is valid for 127.0.0.1
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", srv.TLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	s := &testServer{tcp: ln, answer: answerTestList}
+	go s.serveTCP()
+
+	c := NewClient("tls://" + ln.Addr().String())
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	c.TLSConfig = &tls.Config{RootCAs: pool}
+	if c.Transport != TransportTLS {
+		t.Fatalf("NewClient() transport = %v, want %v", c.Transport, TransportTLS)
+	}
+
+	for i := 0; i < 3; i++ {
+		if addrs, err := c.LookupHost(context.Background(), "2.0.0.127.bl.example.com"); err != nil || len(addrs) != 1 || addrs[0] != "127.0.0.2" {
+			t.Errorf("LookupHost() = %v, %v", addrs, err)
+		}
+	}
+	if accepted := atomic.LoadInt32(&s.accepted); accepted != 1 {
+		t.Errorf("LookupHost() opened %v connections, want 1", accepted)
+	}
+
+	// a broken idle connection is replaced
+	c.mu.Lock()
+	c.idle[0].Close()
+	c.mu.Unlock()
+	if addrs, err := c.LookupHost(context.Background(), "2.0.0.127.bl.example.com"); err != nil || len(addrs) != 1 {
+		t.Errorf("LookupHost() after closed connection = %v, %v", addrs, err)
+	}
+	if accepted := atomic.LoadInt32(&s.accepted); accepted != 2 {
+		t.Errorf("LookupHost() opened %v connections, want 2", accepted)
+	}
+	c.CloseIdleConnections()
+
+	// the server's certificate is not trusted by default
+	c = NewClient("tls://" + ln.Addr().String())
+	if _, err := c.LookupHost(context.Background(), "2.0.0.127.bl.example.com"); err == nil {
+		t.Errorf("LookupHost() with untrusted certificate succeeded")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		server        string
+		wantServer    string
+		wantTransport Transport
+	}{
+		{"192.0.2.53", "192.0.2.53:53", TransportUDP},
+		{"::1", "[::1]:53", TransportUDP},
+		{"192.0.2.53:5353", "192.0.2.53:5353", TransportUDP},
+		{"tls://dns.example.com", "dns.example.com:853", TransportTLS},
+		{"tls://[2001:db8::53]:8853", "[2001:db8::53]:8853", TransportTLS},
+		{"https://dns.example.com/dns-query", "https://dns.example.com/dns-query", TransportHTTPS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.server, func(t *testing.T) {
+			if c := NewClient(tt.server); c.Server != tt.wantServer || c.Transport != tt.wantTransport {
+				t.Errorf("NewClient() = %v %v, want %v %v", c.Server, c.Transport, tt.wantServer, tt.wantTransport)
+			}
+		})
+	}
+}
+
+func TestClient_timeout(t *testing.T) {
+	// nothing answers on this socket
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := NewClient(conn.LocalAddr().String()).LookupHost(ctx, "bl.example.com"); !isTimeout(err) {
+		t.Errorf("LookupHost() error = %v, want timeout", err)
+	}
+}
+
+func Test_unpackName(t *testing.T) {
+	// example.com at 12, www.example.com as a label and a pointer to it at 25
+	msg := make([]byte, 12)
+	msg, _ = packName(msg, "example.com")
+	msg = append(msg, 3, 'w', 'w', 'w', 0xc0, 12)
+
+	if name, off, err := unpackName(msg, 25); err != nil || name != "www.example.com" || off != len(msg) {
+		t.Errorf("unpackName() = %v, %v, %v", name, off, err)
+	}
+
+	// a pointer to itself must not loop forever
+	loop := append(make([]byte, 12), 0xc0, 12)
+	if _, _, err := unpackName(loop, 12); err != errMalformedResponse {
+		t.Errorf("unpackName() error = %v, want %v", err, errMalformedResponse)
+	}
+}
@@ -1,9 +1,9 @@
-package main
+package dnsbl
 
 import (
 	"encoding/csv"
+	"fmt"
 	"io"
-	"log"
 	"strings"
 )
 
@@ -16,18 +16,43 @@ var privateLists = []string{"(hidden)", "blackholes.scconsult.com", "hartkore.dn
 var brokenLists = []string{"ipbl.zeustracker.abuse.ch", "dnsbl.anticaptcha.net", "orvedb.aupads.org", "rsbl.aupads.org",
 	"dnsbl.isx.fr", "dnsbl.openresolvers.org"}
 
+// Reasons why a list from the built-in catalogue is disabled
+const (
+	DisabledPrivate      = "private"
+	DisabledBroken       = "broken"
+	DisabledUnknownCodes = "unknown return codes"
+)
+
+// DefaultLists returns the built-in catalogue of public DNSBLs, without the
+// disabled lists.
+func DefaultLists() []*ListItem {
+	lists := []*ListItem{}
+	for _, item := range parseCVS() {
+		if item.Disabled == "" {
+			lists = append(lists, item)
+		}
+	}
+
+	return lists
+}
+
+// AllLists returns the complete built-in catalogue, including disabled lists
+func AllLists() []*ListItem {
+	return parseCVS()
+}
+
 func parseCVS() []*ListItem {
 	lists := []*ListItem{}
 	r := csv.NewReader(strings.NewReader(csvList))
 
-ListLoop:
 	for {
 		record, err := r.Read()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			log.Fatal(err)
+			// the catalogue is a constant, so an error can only be a mistake in this package
+			panic(fmt.Sprintf("dnsbl: malformed built-in list catalogue: %v", err))
 		}
 
 		item := &ListItem{
@@ -55,23 +80,34 @@ ListLoop:
 			item.Whitelist = true
 		}
 
-		// if it's neither a whitelist nor a blacklist, skip it
-		if !item.Blacklist && !item.Whitelist {
-			continue
+		if record[6] == "c" {
+			item.Combined = true
+		}
+
+		if record[6] == "i" {
+			item.Info = true
+		}
+
+		item.ReturnCodes = returnCodes[item.Address]
+		item.ReturnCodesBitmask = isStringInSlice(item.Address, bitmaskLists)
+		item.BlockedCodes = blockedCodes[item.Address]
+		item.BlacklistCodes = blacklistCodes[item.Address]
+		item.WhitelistCodes = whitelistCodes[item.Address]
+		item.Weight = listWeights[item.Address]
+
+		// disable combined lists whose answers can't be interpreted as listed or whitelisted
+		if item.Combined && len(item.BlacklistCodes) == 0 && len(item.WhitelistCodes) == 0 {
+			item.Disabled = DisabledUnknownCodes
 		}
 
-		// remove private DNSBLs that don't work for public
-		for _, v := range privateLists {
-			if item.Address == v {
-				continue ListLoop
-			}
+		// disable private DNSBLs that don't work for public
+		if isStringInSlice(item.Address, privateLists) {
+			item.Disabled = DisabledPrivate
 		}
 
-		// remove broken DNSBLs that don't respond
-		for _, v := range brokenLists {
-			if item.Address == v {
-				continue ListLoop
-			}
+		// disable broken DNSBLs that don't respond
+		if isStringInSlice(item.Address, brokenLists) {
+			item.Disabled = DisabledBroken
 		}
 
 		lists = append(lists, item)
@@ -0,0 +1,40 @@
+package dnsbl
+
+import "testing"
+
+func TestDefaultLists(t *testing.T) {
+	all := AllLists()
+	lists := DefaultLists()
+
+	if len(all) != 326 {
+		t.Errorf("AllLists() returned %v lists, want 326", len(all))
+	}
+
+	disabled := map[string]string{}
+	for _, item := range all {
+		disabled[item.Address] = item.Disabled
+	}
+
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"zen.spamhaus.org", ""},
+		{"rbl.tdk.net", DisabledPrivate},
+		{"dnsbl.isx.fr", DisabledBroken},
+		{"contacts.abuse.net", ""},
+		{"hostkarma.junkemailfilter.com", ""},
+		{"sa.fmb.la", DisabledUnknownCodes},
+	}
+	for _, tt := range tests {
+		if got := disabled[tt.address]; got != tt.want {
+			t.Errorf("%v Disabled = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+
+	for _, item := range lists {
+		if item.Disabled != "" {
+			t.Errorf("DefaultLists() contains disabled list %v", item.Address)
+		}
+	}
+}
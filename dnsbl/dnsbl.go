@@ -0,0 +1,95 @@
+// Package dnsbl checks IPv4 addresses, IPv6 addresses and domains against
+// DNS-based blacklists and whitelists (RFC 5782).
+package dnsbl
+
+import "fmt"
+
+var (
+	ErrWrongResponse   = fmt.Errorf("RBL returned a response outside of 127.0.0.0/8 subnet")
+	ErrRBLPositiveFail = fmt.Errorf("RBL failed positive check")
+	ErrRBLNegativeFail = fmt.Errorf("RBL failed negative check")
+	ErrRBLFail         = fmt.Errorf("RBL failed both checks")
+	ErrQueryBlocked    = fmt.Errorf("RBL refused the query, use a different resolver")
+	ErrInvalidAddress  = fmt.Errorf("not a valid IP address")
+)
+
+// ListItem is a struct with list details
+type ListItem struct {
+	// Name is the name of the list
+	Name string
+	// Address is the hostname used for checking
+	Address string
+	// IP4 is true if this list is used for checking IP4 addresses
+	IP4 bool
+	// IP6 is true if this list is used for checking IP6 addresses
+	IP6 bool
+	// Domain is true if this list is used for checking domains
+	Domain bool
+	// Blacklist is true if this list is a blacklist
+	Blacklist bool
+	// Whitelist is true if this list is a whitelist
+	Whitelist bool
+	// Combined is true if this list is a combined blacklist and whitelist
+	Combined bool
+	// Info is true if this list is an informational list, e.g. an abuse contact database
+	Info bool
+	// Disabled is the reason this list is not used by default. Empty if it is used.
+	Disabled string
+	// ReturnCodes maps the addresses returned by this list to their meaning
+	ReturnCodes map[string]string
+	// ReturnCodesBitmask is true if this list combines several ReturnCodes into a single answer
+	ReturnCodesBitmask bool
+	// BlockedCodes maps the addresses this list returns instead of an answer
+	// when it refuses a query (e.g. from a public resolver) to their meaning
+	BlockedCodes map[string]string
+	// BlacklistCodes are the codes of a combined list that mean the target is listed.
+	// If empty, every code that is not in WhitelistCodes means the target is listed.
+	BlacklistCodes []string
+	// WhitelistCodes are the codes of a combined list that mean the target is whitelisted
+	WhitelistCodes []string
+	// Weight is the importance of a hit on this list
+	Weight float64
+}
+
+// checks returns true if this list is used when checking blacklists, or whitelists if `whitelist` is true.
+// Combined lists are used for both.
+func (l *ListItem) checks(whitelist bool) bool {
+	switch {
+	case l.Combined:
+		return true
+	case whitelist:
+		return l.Whitelist
+	}
+
+	return l.Blacklist
+}
+
+// isListed returns true if `codes` returned by this list mean that the target is
+// blacklisted, or whitelisted if `whitelist` is true. Answers of lists that are
+// not combined always mean the target is listed.
+func (l *ListItem) isListed(codes []string, whitelist bool) bool {
+	if !l.Combined {
+		return true
+	}
+
+	for _, code := range codes {
+		white := isStringInSlice(code, l.WhitelistCodes)
+		black := !white && (len(l.BlacklistCodes) == 0 || isStringInSlice(code, l.BlacklistCodes))
+		if (whitelist && white) || (!whitelist && black) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isStringInSlice returns true if `needle` is in `haystrack`
+func isStringInSlice(needle string, haystrack []string) bool {
+	for _, v := range haystrack {
+		if needle == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,108 @@
+package dnsbl
+
+import (
+	"context"
+	"sync"
+)
+
+// healthResult is the outcome of a list's health check, shared by all checks of a Checker
+type healthResult struct {
+	mu   sync.Mutex
+	done bool
+	err  error
+}
+
+// listHealth returns the health of `list` for queries of `kind`. The health
+// check `check` is only run the first time, after that its result is reused.
+// The check is skipped if the HealthCache has a recent healthy result. A check
+// that is aborted because `ctx` is done is not reused.
+func (c *Checker) listHealth(ctx context.Context, kind string, list *ListItem, check func(context.Context, Resolver, *ListItem) error) error {
+	c.healthMu.Lock()
+	if c.health == nil {
+		c.health = map[string]*healthResult{}
+	}
+	key := kind + ":" + list.Address
+	hr, ok := c.health[key]
+	if !ok {
+		hr = &healthResult{}
+		c.health[key] = hr
+	}
+	c.healthMu.Unlock()
+
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	if hr.done {
+		return hr.err
+	}
+	if c.HealthCache != nil && c.HealthCache.Healthy(key) {
+		hr.done = true
+		return nil
+	}
+
+	err := check(ctx, c.resolver(), list)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	hr.done, hr.err = true, err
+	if err == nil && c.HealthCache != nil {
+		c.HealthCache.SetHealthy(key)
+	}
+
+	return err
+}
+
+// ResetHealth forgets the health of all lists, so they are checked again on their
+// next use unless the HealthCache has a recent healthy result. Long-running
+// programs should call it periodically.
+func (c *Checker) ResetHealth() {
+	c.healthMu.Lock()
+	c.health = nil
+	c.healthMu.Unlock()
+}
+
+// checkIP4Health returns nil if `list` is healthy. Returns the failed test otherwise.
+// Test specification: https://tools.ietf.org/html/rfc5782#page-7
+func checkIP4Health(ctx context.Context, r Resolver, list *ListItem) error {
+	return checkListHealth(ctx, r, list, "1.0.0.127"+"."+list.Address, "2.0.0.127"+"."+list.Address)
+}
+
+// checkIP6Health returns nil if `list` is healthy. Returns the failed test otherwise.
+// Test specification: https://tools.ietf.org/html/rfc5782#page-7
+func checkIP6Health(ctx context.Context, r Resolver, list *ListItem) error {
+	return checkListHealth(ctx, r, list, reverseIP6("::1")+"."+list.Address, reverseIP6("::FFFF:7F00:2")+"."+list.Address)
+}
+
+// checkDomainHealth returns nil if `list` is healthy. Returns the failed test otherwise.
+// Test specification: https://tools.ietf.org/html/rfc5782#page-7
+func checkDomainHealth(ctx context.Context, r Resolver, list *ListItem) error {
+	return checkListHealth(ctx, r, list, "INVALID"+"."+list.Address, "TEST"+"."+list.Address)
+}
+
+// checkListHealth returns nil if `negative` is not listed and `positive` is listed.
+// Returns a *blockedError if `list` refused to answer, or the failed test otherwise.
+func checkListHealth(ctx context.Context, r Resolver, list *ListItem, negative, positive string) error {
+	negIPs, negErr := r.LookupHost(ctx, negative)
+	posIPs, posErr := r.LookupHost(ctx, positive)
+
+	if list.isBlocked(posIPs) {
+		return &blockedError{codes: posIPs}
+	}
+	if list.isBlocked(negIPs) {
+		return &blockedError{codes: negIPs}
+	}
+
+	negResult := len(negIPs) == 0 && isNotFound(negErr)
+	posResult := len(posIPs) > 0 && posErr == nil
+
+	if !negResult && !posResult {
+		return ErrRBLFail
+	} else if negResult && !posResult {
+		return ErrRBLPositiveFail
+	} else if !negResult && posResult {
+		return ErrRBLNegativeFail
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package dnsbl
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// HealthCache remembers which lists passed their health check, so the check
+// can be skipped until the result is older than TTL. Only healthy results are
+// cached. A HealthCache can be saved to disk and reused between runs.
+type HealthCache struct {
+	// Path is the file the cache is loaded from and saved to
+	Path string
+	// TTL is how long a health check result is valid for
+	TTL time.Duration
+	// Resolver identifies the resolver the health checks are made through. A list
+	// that is healthy through one resolver may refuse queries from another, so
+	// results are only reused for the same Resolver.
+	Resolver string
+	// Refresh is true if cached results are ignored, so every list is checked
+	// again. The results of other resolvers are kept.
+	Refresh bool
+
+	mu      sync.Mutex
+	healthy map[string]time.Time
+}
+
+// LoadHealthCache returns a HealthCache stored in the file at `path`. A
+// missing file results in an empty cache.
+func LoadHealthCache(path string, ttl time.Duration) (*HealthCache, error) {
+	hc := &HealthCache{
+		Path:    path,
+		TTL:     ttl,
+		healthy: map[string]time.Time{},
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return hc, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &hc.healthy); err != nil {
+		return nil, err
+	}
+
+	return hc, nil
+}
+
+// Healthy returns true if the list identified by `key` passed its health check within TTL
+func (hc *HealthCache) Healthy(key string) bool {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if hc.Refresh {
+		// the old result is dropped, so a list that fails the new check is not healthy in later runs
+		delete(hc.healthy, hc.key(key))
+		return false
+	}
+
+	t, ok := hc.healthy[hc.key(key)]
+	return ok && time.Since(t) < hc.TTL
+}
+
+// SetHealthy records that the list identified by `key` just passed its health check
+func (hc *HealthCache) SetHealthy(key string) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if hc.healthy == nil {
+		hc.healthy = map[string]time.Time{}
+	}
+	hc.healthy[hc.key(key)] = time.Now()
+}
+
+// key returns the cache key of the list identified by `key` for the cache's Resolver
+func (hc *HealthCache) key(key string) string {
+	if hc.Resolver == "" {
+		return key
+	}
+
+	return hc.Resolver + " " + key
+}
+
+// Save writes all results that are still valid to the cache file
+func (hc *HealthCache) Save() error {
+	hc.mu.Lock()
+	valid := map[string]time.Time{}
+	for k, t := range hc.healthy {
+		if time.Since(t) < hc.TTL {
+			valid[k] = t
+		}
+	}
+	hc.mu.Unlock()
+
+	data, err := json.Marshal(valid)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(hc.Path), 0755); err != nil {
+		return err
+	}
+
+	tmp := hc.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, hc.Path)
+}
@@ -0,0 +1,117 @@
+package dnsbl
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHealthCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dnsbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cache", "health.json")
+
+	hc, err := LoadHealthCache(path, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadHealthCache() error = %v", err)
+	}
+	if hc.Healthy("ip4:bl.example.com") {
+		t.Errorf("Healthy() = true for empty cache")
+	}
+
+	hc.SetHealthy("ip4:bl.example.com")
+	if err := hc.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	hc, err = LoadHealthCache(path, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadHealthCache() error = %v", err)
+	}
+	if !hc.Healthy("ip4:bl.example.com") {
+		t.Errorf("Healthy() = false after reload")
+	}
+	if hc.Healthy("domain:bl.example.com") {
+		t.Errorf("Healthy() = true for unknown key")
+	}
+
+	hc.Resolver = "tls://dns.example.com:853"
+	if hc.Healthy("ip4:bl.example.com") {
+		t.Errorf("Healthy() = true for a different resolver")
+	}
+	hc.Resolver = ""
+
+	hc.TTL = 0
+	if hc.Healthy("ip4:bl.example.com") {
+		t.Errorf("Healthy() = true for expired result")
+	}
+}
+
+func TestHealthCache_refresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dnsbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "health.json")
+
+	hc := &HealthCache{Path: path, TTL: time.Hour, Resolver: "system"}
+	hc.SetHealthy("ip4:bl.example.com")
+	hc.Resolver = "builtin 192.0.2.53:53"
+	hc.SetHealthy("ip4:bl.example.com")
+	if err := hc.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	hc, err = LoadHealthCache(path, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadHealthCache() error = %v", err)
+	}
+	hc.Resolver, hc.Refresh = "system", true
+	if hc.Healthy("ip4:bl.example.com") {
+		t.Errorf("Healthy() = true with Refresh")
+	}
+	if err := hc.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// only the refreshed result is dropped, other resolvers keep theirs
+	hc, err = LoadHealthCache(path, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadHealthCache() error = %v", err)
+	}
+	hc.Resolver = "system"
+	if hc.Healthy("ip4:bl.example.com") {
+		t.Errorf("Healthy() = true for a refreshed result that was not checked again")
+	}
+	hc.Resolver = "builtin 192.0.2.53:53"
+	if !hc.Healthy("ip4:bl.example.com") {
+		t.Errorf("Healthy() = false for a different resolver after refresh")
+	}
+}
+
+func TestChecker_listHealthCache(t *testing.T) {
+	r := &countingResolver{fakeResolver: &fakeResolver{}}
+	list := &ListItem{Address: "bl.example.com"}
+
+	c := NewChecker(nil)
+	c.Resolver = r
+	c.HealthCache = &HealthCache{TTL: time.Hour}
+	c.HealthCache.SetHealthy("ip4:bl.example.com")
+
+	if err := c.listHealth(context.Background(), "ip4", list, checkIP4Health); err != nil || r.queries != 0 {
+		t.Errorf("listHealth() = %v after %v queries, want cached result", err, r.queries)
+	}
+	if err := c.listHealth(context.Background(), "domain", list, checkDomainHealth); err != ErrRBLPositiveFail || r.queries != 2 {
+		t.Errorf("listHealth() = %v after %v queries, want health check", err, r.queries)
+	}
+	if c.HealthCache.Healthy("domain:bl.example.com") {
+		t.Errorf("unhealthy result was cached")
+	}
+}
@@ -0,0 +1,87 @@
+package dnsbl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func Test_checkIP4Health(t *testing.T) {
+	type args struct {
+		list string
+	}
+	tests := []struct {
+		name string
+		args args
+		want error
+	}{
+		{"working RBL 1 - blacklist", args{"zen.spamhaus.org"}, nil},
+		{"working RBL 2 - whitelist", args{"swl.spamhaus.org"}, nil},
+		// {"working RBL 3", args{"b.barracudacentral.org"}, true},
+		// {"working RBL 4", args{"dnsbl-0.uceprotect.net"}, true},
+		// {"working RBL 5", args{"bl.spamcop.net"}, true},
+		{"random domain", args{"www.example.com"}, ErrRBLPositiveFail},
+		{"non-existant domain", args{"12345.invaliddomain871253659dfd.com"}, ErrRBLPositiveFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkIP4Health(context.Background(), net.DefaultResolver, &ListItem{Address: tt.args.list}); got != tt.want {
+				t.Errorf("checkIP4Health() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkDomainHealth(t *testing.T) {
+	type args struct {
+		list string
+	}
+	tests := []struct {
+		name string
+		args args
+		want error
+	}{
+		{"working RBL 1 - blacklist", args{"dbl.spamhaus.org"}, nil},
+		// {"working RBL 3", args{"b.barracudacentral.org"}, true},
+		// {"working RBL 4", args{"dnsbl-0.uceprotect.net"}, true},
+		// {"working RBL 5", args{"bl.spamcop.net"}, true},
+		{"random domain", args{"www.example.com"}, ErrRBLPositiveFail},
+		{"non-existant domain", args{"12345.invaliddomain871253659dfd.com"}, ErrRBLPositiveFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkDomainHealth(context.Background(), net.DefaultResolver, &ListItem{Address: tt.args.list}); got != tt.want {
+				t.Errorf("checkDomainHealth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkListHealth(t *testing.T) {
+	r := &fakeResolver{hosts: map[string][]string{
+		"listed.example.com":  {"127.0.0.2"},
+		"blocked.example.com": {"127.255.255.254"},
+	}}
+	list := &ListItem{BlockedCodes: map[string]string{"127.255.255.254": "Query via public resolver"}}
+
+	tests := []struct {
+		name     string
+		negative string
+		positive string
+		want     error
+	}{
+		{"healthy", "unlisted.example.com", "listed.example.com", nil},
+		{"negative fail", "listed.example.com", "listed.example.com", ErrRBLNegativeFail},
+		{"positive fail", "unlisted.example.com", "unlisted.example.com", ErrRBLPositiveFail},
+		{"both fail", "listed.example.com", "unlisted.example.com", ErrRBLFail},
+		{"blocked", "blocked.example.com", "blocked.example.com", ErrQueryBlocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkListHealth(context.Background(), r, list, tt.negative, tt.positive); got != tt.want && !errors.Is(got, tt.want) {
+				t.Errorf("checkListHealth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
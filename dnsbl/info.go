@@ -0,0 +1,56 @@
+package dnsbl
+
+import (
+	"context"
+	"net"
+)
+
+// Info queries all informational lists, like abuse contact databases, that
+// support `target` and returns their answers. `target` is an IPv4 address, an
+// IPv6 address or a domain. The answers of lists that know about `target` are
+// in the TXT and ReturnCodes of results with StatusHit.
+func (c *Checker) Info(target string) *Report {
+	return c.InfoContext(context.Background(), target)
+}
+
+// InfoContext is like Info. Queries that are outstanding when `ctx` is done are aborted.
+func (c *Checker) InfoContext(ctx context.Context, target string) *Report {
+	ip := net.ParseIP(target)
+	if ip != nil && ip.To4() != nil {
+		// IPv4-mapped IPv6 addresses are looked up in their IPv4 form
+		target = ip.To4().String()
+	}
+	lists := []*ListItem{}
+	for _, v := range c.Lists {
+		if !v.Info {
+			continue
+		}
+		if (ip == nil && v.Domain) || (ip != nil && ip.To4() != nil && v.IP4) || (ip != nil && ip.To4() == nil && v.IP6) {
+			lists = append(lists, v)
+		}
+	}
+
+	return c.run(ctx, []check{{target: target, lists: lists, lookupFunc: c.lookupInfo}})[0]
+}
+
+// lookupInfo returns the TXT and A records that `list` publishes about `target`, or nil if there are none.
+// Informational lists don't follow RFC 5782, so they are not health checked and their answers
+// don't have to be inside 127.0.0.0/8.
+func (c *Checker) lookupInfo(ctx context.Context, target string, list *ListItem) (*listing, error) {
+	name := target
+	if ip := net.ParseIP(target); ip != nil && ip.To4() != nil {
+		name = reverseIP4(ip.To4().String())
+	} else if ip != nil {
+		name = reverseIP6(target)
+	}
+	name += "." + list.Address
+
+	r := c.resolver()
+	txt, _ := r.LookupTXT(ctx, name)
+	addrs, err := r.LookupHost(ctx, name)
+	if len(txt) == 0 && len(addrs) == 0 {
+		return nil, err
+	}
+
+	return &listing{codes: addrs, txt: txt}, nil
+}
@@ -0,0 +1,41 @@
+package dnsbl
+
+import "testing"
+
+func TestChecker_Info(t *testing.T) {
+	r := &fakeResolver{
+		hosts: map[string][]string{},
+		txt: map[string][]string{
+			"1.2.0.192.abuse-contacts.abusix.org": {"abuse@example.net"},
+			"example.com.contacts.abuse.net":      {"abuse@example.com"},
+		},
+	}
+
+	c := NewChecker([]*ListItem{
+		{Address: "abuse-contacts.abusix.org", IP4: true, Info: true},
+		{Address: "contacts.abuse.net", Domain: true, Info: true},
+		{Address: "zen.spamhaus.org", IP4: true, Blacklist: true},
+	})
+	c.Resolver = r
+
+	report := c.Info("192.0.2.1")
+	if len(report.Results) != 1 || report.Hits != 1 || report.Results[0].TXT[0] != "abuse@example.net" {
+		t.Errorf("Info() = %+v", report)
+	}
+
+	report = c.Info("example.com")
+	if len(report.Results) != 1 || report.Hits != 1 || report.Results[0].TXT[0] != "abuse@example.com" {
+		t.Errorf("Info() = %+v", report)
+	}
+
+	// ::ffff:c000:201 is 192.0.2.1
+	report = c.Info("::ffff:c000:201")
+	if report.Target != "192.0.2.1" || report.Hits != 1 {
+		t.Errorf("Info() of IPv4-mapped address = %+v", report)
+	}
+
+	report = c.Info("192.0.2.2")
+	if report.Hits != 0 || report.Misses != 1 {
+		t.Errorf("Info() = %+v, want a miss", report)
+	}
+}
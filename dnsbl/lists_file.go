@@ -0,0 +1,255 @@
+package dnsbl
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// listsFile is the layout of a YAML or JSON list definitions file
+type listsFile struct {
+	Lists []*listDefinition `json:"lists" yaml:"lists"`
+}
+
+// listDefinition is a single list in a list definitions file
+type listDefinition struct {
+	Address            string            `json:"address" yaml:"address"`
+	Name               string            `json:"name" yaml:"name"`
+	Capabilities       []string          `json:"capabilities" yaml:"capabilities"`
+	Type               string            `json:"type" yaml:"type"`
+	ReturnCodes        map[string]string `json:"return_codes" yaml:"return_codes"`
+	ReturnCodesBitmask bool              `json:"return_codes_bitmask" yaml:"return_codes_bitmask"`
+	BlockedCodes       map[string]string `json:"blocked_codes" yaml:"blocked_codes"`
+	BlacklistCodes     []string          `json:"blacklist_codes" yaml:"blacklist_codes"`
+	WhitelistCodes     []string          `json:"whitelist_codes" yaml:"whitelist_codes"`
+	Weight             float64           `json:"weight" yaml:"weight"`
+}
+
+// LoadLists reads list definitions from the file at `path`. The format is
+// chosen by the file extension: .yaml, .yml, .json or .csv.
+func LoadLists(path string) ([]*ListItem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lists, err := ParseLists(f, strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+
+	return lists, nil
+}
+
+// ParseLists reads list definitions in `format` (yaml, yml, json or csv) from `r`.
+//
+// YAML and JSON files contain a "lists" array. CSV files start with a header
+// row naming the columns address, name, capabilities, type, return_codes,
+// return_codes_bitmask, blocked_codes, blacklist_codes, whitelist_codes and
+// weight. Capabilities and blacklist and whitelist codes are separated by
+// spaces. Return and blocked codes are written as "127.0.0.2=meaning;127.0.0.3=meaning".
+func ParseLists(r io.Reader, format string) ([]*ListItem, error) {
+	var defs []*listDefinition
+
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		file := &listsFile{}
+		if err := yaml.UnmarshalStrict(data, file); err != nil {
+			return nil, err
+		}
+		defs = file.Lists
+
+	case "json":
+		file := &listsFile{}
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(file); err != nil {
+			return nil, err
+		}
+		defs = file.Lists
+
+	case "csv":
+		var err error
+		if defs, err = parseListsCSV(r); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported list definitions format %q", format)
+	}
+
+	lists := make([]*ListItem, 0, len(defs))
+	for i, def := range defs {
+		item, err := def.listItem()
+		if err != nil {
+			return nil, fmt.Errorf("list %v: %v", i+1, err)
+		}
+		lists = append(lists, item)
+	}
+
+	return lists, nil
+}
+
+// parseListsCSV reads list definitions from a CSV file with a header row
+func parseListsCSV(r io.Reader) ([]*listDefinition, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["address"]; !ok {
+		return nil, fmt.Errorf("missing address column")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	defs := make([]*listDefinition, 0, len(records)-1)
+	for i, record := range records[1:] {
+		def := &listDefinition{
+			Address:      field(record, "address"),
+			Name:         field(record, "name"),
+			Capabilities: strings.Fields(field(record, "capabilities")),
+			Type:         field(record, "type"),
+		}
+
+		def.ReturnCodes = parseCodesCSV(field(record, "return_codes"))
+		def.BlockedCodes = parseCodesCSV(field(record, "blocked_codes"))
+		def.BlacklistCodes = strings.Fields(field(record, "blacklist_codes"))
+		def.WhitelistCodes = strings.Fields(field(record, "whitelist_codes"))
+
+		if bitmask := field(record, "return_codes_bitmask"); bitmask != "" {
+			if def.ReturnCodesBitmask, err = strconv.ParseBool(bitmask); err != nil {
+				return nil, fmt.Errorf("line %v: invalid return_codes_bitmask %q", i+2, bitmask)
+			}
+		}
+
+		if weight := field(record, "weight"); weight != "" {
+			if def.Weight, err = strconv.ParseFloat(weight, 64); err != nil {
+				return nil, fmt.Errorf("line %v: invalid weight %q", i+2, weight)
+			}
+		}
+
+		defs = append(defs, def)
+	}
+
+	return defs, nil
+}
+
+// parseCodesCSV returns the codes written as "127.0.0.2=meaning;127.0.0.3=meaning". Nil if `codes` is empty.
+func parseCodesCSV(codes string) map[string]string {
+	if codes == "" {
+		return nil
+	}
+
+	parsed := map[string]string{}
+	for _, pair := range strings.Split(codes, ";") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 {
+			parsed[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		} else {
+			parsed[strings.TrimSpace(kv[0])] = ""
+		}
+	}
+
+	return parsed
+}
+
+// listItem validates the definition and converts it to a ListItem
+func (def *listDefinition) listItem() (*ListItem, error) {
+	if def.Address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+
+	item := &ListItem{
+		Name:               def.Name,
+		Address:            def.Address,
+		ReturnCodes:        def.ReturnCodes,
+		ReturnCodesBitmask: def.ReturnCodesBitmask,
+		BlockedCodes:       def.BlockedCodes,
+		BlacklistCodes:     def.BlacklistCodes,
+		WhitelistCodes:     def.WhitelistCodes,
+		Weight:             def.Weight,
+	}
+	if item.Name == "" {
+		item.Name = item.Address
+	}
+
+	for _, capability := range def.Capabilities {
+		switch strings.ToLower(capability) {
+		case "ip4", "ipv4":
+			item.IP4 = true
+		case "ip6", "ipv6":
+			item.IP6 = true
+		case "domain", "dom":
+			item.Domain = true
+		default:
+			return nil, fmt.Errorf("%v: unknown capability %q", def.Address, capability)
+		}
+	}
+
+	switch strings.ToLower(def.Type) {
+	case "black", "b", "":
+		item.Blacklist = true
+	case "white", "w":
+		item.Whitelist = true
+	case "combined", "c":
+		item.Combined = true
+		if len(item.BlacklistCodes) == 0 && len(item.WhitelistCodes) == 0 {
+			item.Disabled = DisabledUnknownCodes
+		}
+	case "info", "i":
+		item.Info = true
+	default:
+		return nil, fmt.Errorf("%v: unknown type %q", def.Address, def.Type)
+	}
+
+	return item, nil
+}
+
+// MergeLists returns the lists in `base` followed by the lists in `extra`.
+// A list in `extra` replaces the list in `base` with the same address.
+func MergeLists(base, extra []*ListItem) []*ListItem {
+	merged := make([]*ListItem, 0, len(base)+len(extra))
+	index := map[string]int{}
+
+	for _, item := range base {
+		index[item.Address] = len(merged)
+		merged = append(merged, item)
+	}
+
+	for _, item := range extra {
+		if i, ok := index[item.Address]; ok {
+			merged[i] = item
+			continue
+		}
+		index[item.Address] = len(merged)
+		merged = append(merged, item)
+	}
+
+	return merged
+}
@@ -0,0 +1,109 @@
+package dnsbl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLists(t *testing.T) {
+	yamlLists := `
+lists:
+  - address: key.zen.dq.spamhaus.net
+    name: Spamhaus ZEN DQS
+    capabilities: [ip4, ip6]
+    type: black
+    return_codes:
+      127.0.0.2: SBL
+      127.0.0.10: PBL
+    weight: 10
+  - address: rbl.example.com
+    capabilities: [domain]
+    type: white
+`
+	jsonLists := `{"lists": [
+		{"address": "key.zen.dq.spamhaus.net", "name": "Spamhaus ZEN DQS", "capabilities": ["ip4", "ip6"], "type": "black",
+		 "return_codes": {"127.0.0.2": "SBL", "127.0.0.10": "PBL"}, "weight": 10},
+		{"address": "rbl.example.com", "capabilities": ["domain"], "type": "white"}
+	]}`
+	csvLists := `address,name,capabilities,type,return_codes,weight
+key.zen.dq.spamhaus.net,Spamhaus ZEN DQS,ip4 ip6,black,127.0.0.2=SBL;127.0.0.10=PBL,10
+rbl.example.com,,domain,white,,
+`
+
+	for format, input := range map[string]string{"yaml": yamlLists, "json": jsonLists, "csv": csvLists} {
+		t.Run(format, func(t *testing.T) {
+			lists, err := ParseLists(strings.NewReader(input), format)
+			if err != nil {
+				t.Fatalf("ParseLists() error = %v", err)
+			}
+			if len(lists) != 2 {
+				t.Fatalf("ParseLists() returned %v lists, want 2", len(lists))
+			}
+
+			dqs := lists[0]
+			if dqs.Address != "key.zen.dq.spamhaus.net" || dqs.Name != "Spamhaus ZEN DQS" || !dqs.IP4 || !dqs.IP6 || dqs.Domain ||
+				!dqs.Blacklist || dqs.Weight != 10 || dqs.ReturnCodes["127.0.0.10"] != "PBL" {
+				t.Errorf("ParseLists()[0] = %+v", dqs)
+			}
+
+			wl := lists[1]
+			if wl.Name != "rbl.example.com" || !wl.Domain || !wl.Whitelist || wl.Blacklist {
+				t.Errorf("ParseLists()[1] = %+v", wl)
+			}
+		})
+	}
+}
+
+func TestParseLists_csvCodes(t *testing.T) {
+	input := `address,capabilities,type,return_codes,return_codes_bitmask,blocked_codes,blacklist_codes,whitelist_codes
+combined.example.com,ip4,combined,127.0.0.1=white;127.0.0.2=black,true,127.255.255.254=public resolver,127.0.0.2 127.0.0.4,127.0.0.1
+`
+	lists, err := ParseLists(strings.NewReader(input), "csv")
+	if err != nil {
+		t.Fatalf("ParseLists() error = %v", err)
+	}
+
+	l := lists[0]
+	if !l.Combined || l.Disabled != "" || !l.ReturnCodesBitmask || l.BlockedCodes["127.255.255.254"] != "public resolver" ||
+		len(l.BlacklistCodes) != 2 || l.BlacklistCodes[1] != "127.0.0.4" || len(l.WhitelistCodes) != 1 || l.WhitelistCodes[0] != "127.0.0.1" {
+		t.Errorf("ParseLists()[0] = %+v", l)
+	}
+}
+
+func TestParseLists_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format string
+	}{
+		{"missing address", "lists:\n  - name: foo\n", "yaml"},
+		{"unknown capability", "lists:\n  - address: bl.example.com\n    capabilities: [ip5]\n", "yaml"},
+		{"unknown type", `{"lists": [{"address": "bl.example.com", "type": "grey"}]}`, "json"},
+		{"unknown field", `{"lists": [{"address": "bl.example.com", "adress": "x"}]}`, "json"},
+		{"invalid weight", "address,weight\nbl.example.com,heavy\n", "csv"},
+		{"invalid bitmask", "address,return_codes_bitmask\nbl.example.com,maybe\n", "csv"},
+		{"unknown format", "", "xml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseLists(strings.NewReader(tt.input), tt.format); err == nil {
+				t.Errorf("ParseLists() error = nil")
+			}
+		})
+	}
+}
+
+func TestMergeLists(t *testing.T) {
+	base := []*ListItem{{Address: "a.example.com", Name: "A"}, {Address: "b.example.com", Name: "B"}}
+	extra := []*ListItem{{Address: "b.example.com", Name: "B2"}, {Address: "c.example.com", Name: "C"}}
+
+	merged := MergeLists(base, extra)
+
+	names := []string{}
+	for _, item := range merged {
+		names = append(names, item.Name)
+	}
+	if strings.Join(names, " ") != "A B2 C" {
+		t.Errorf("MergeLists() = %v, want [A B2 C]", names)
+	}
+}
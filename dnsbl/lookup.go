@@ -0,0 +1,136 @@
+package dnsbl
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// listing is the answer of a list that has the target listed
+type listing struct {
+	// codes are the addresses returned by the list
+	codes []string
+	// txt are the TXT records published at the same query name
+	txt []string
+}
+
+// blockedError is ErrQueryBlocked with the codes the list answered with instead of a listing
+type blockedError struct {
+	codes []string
+}
+
+func (e *blockedError) Error() string {
+	return ErrQueryBlocked.Error()
+}
+
+func (e *blockedError) Unwrap() error {
+	return ErrQueryBlocked
+}
+
+// lookupIP4 returns the listing of `ip` in `list`, or nil if `ip` is not listed
+func (c *Checker) lookupIP4(ctx context.Context, ip string, list *ListItem) (*listing, error) {
+	// check RBL health before using it
+	if err := c.listHealth(ctx, "ip4", list, checkIP4Health); err != nil {
+		return nil, err
+	}
+
+	reversed := reverseIP4(ip)
+	if reversed == "" {
+		return nil, ErrInvalidAddress
+	}
+
+	return lookupListing(ctx, c.resolver(), list, reversed+"."+list.Address)
+}
+
+// lookupIP6 returns the listing of `ip` in `list`, or nil if `ip` is not listed
+func (c *Checker) lookupIP6(ctx context.Context, ip string, list *ListItem) (*listing, error) {
+	// check RBL health before using it
+	if err := c.listHealth(ctx, "ip6", list, checkIP6Health); err != nil {
+		return nil, err
+	}
+
+	reversed := reverseIP6(ip)
+	if reversed == "" {
+		return nil, ErrInvalidAddress
+	}
+
+	return lookupListing(ctx, c.resolver(), list, reversed+"."+list.Address)
+}
+
+// lookupListing returns the listing published by `list` at the query name `addr`, or nil if there is none.
+// TXT records are only fetched for listed targets and a failed TXT lookup is not an error.
+// If `list` refused the query, the error is a *blockedError with the codes that explain why.
+func lookupListing(ctx context.Context, r Resolver, list *ListItem, addr string) (*listing, error) {
+	codes, err := lookupAddr(ctx, r, addr)
+	if err != nil || len(codes) == 0 {
+		return nil, err
+	}
+
+	if list.isBlocked(codes) {
+		return nil, &blockedError{codes: codes}
+	}
+
+	txt, _ := r.LookupTXT(ctx, addr)
+
+	return &listing{codes: codes, txt: txt}, nil
+}
+
+// lookupAddr returns the addresses that the query name `addr` resolves to.
+// All addresses must be inside 127.0.0.0/8.
+func lookupAddr(ctx context.Context, r Resolver, addr string) ([]string, error) {
+	addrs, err := r.LookupHost(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	_, subNet, _ := net.ParseCIDR("127.0.0.0/8")
+	codes := make([]string, 0, len(addrs))
+	for _, v := range addrs {
+		ip := net.ParseIP(v)
+		if ip == nil || !subNet.Contains(ip) {
+			return nil, ErrWrongResponse
+		}
+		codes = append(codes, ip.String())
+	}
+
+	return codes, nil
+}
+
+// lookupDomain returns the listing of `domain` in `list`, or nil if `domain` is not listed
+func (c *Checker) lookupDomain(ctx context.Context, domain string, list *ListItem) (*listing, error) {
+	// check RBL health before using it
+	if err := c.listHealth(ctx, "domain", list, checkDomainHealth); err != nil {
+		return nil, err
+	}
+
+	return lookupListing(ctx, c.resolver(), list, domain+"."+list.Address)
+}
+
+// reverseIP4 returns the octets of `ip` in reverse order, e.g. 192.0.2.1 becomes 1.2.0.192.
+// Returns an empty string if `ip` is not an IPv4 address.
+func reverseIP4(ip string) string {
+	ip4 := net.ParseIP(ip).To4()
+	if ip4 == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d.%d.%d.%d", ip4[3], ip4[2], ip4[1], ip4[0])
+}
+
+// reverseIP6 returns the nibbles of `ip` in reverse order, separated by dots.
+// Query name specification: https://tools.ietf.org/html/rfc5782#section-2.4
+func reverseIP6(ip string) string {
+	ip16 := net.ParseIP(ip).To16()
+	if ip16 == nil {
+		return ""
+	}
+
+	nibbles := make([]byte, 0, 64)
+	for i := len(ip16) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, hexDigits[ip16[i]&0x0f], '.', hexDigits[ip16[i]>>4], '.')
+	}
+
+	return string(nibbles[:len(nibbles)-1])
+}
@@ -0,0 +1,97 @@
+package dnsbl
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func Test_reverseIP4(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		want string
+	}{
+		{"address", "192.0.2.1", "1.2.0.192"},
+		{"IPv4-mapped", "::ffff:102:304", "4.3.2.1"},
+		{"IPv6", "2001:db8::1", ""},
+		{"invalid", "192.0.2", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reverseIP4(tt.ip); got != tt.want {
+				t.Errorf("reverseIP4() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_reverseIP6(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		want string
+	}{
+		{"RFC 5782 example", "2001:db8:1:2:3:4:567:89ab", "b.a.9.8.7.6.5.0.4.0.0.0.3.0.0.0.2.0.0.0.1.0.0.0.8.b.d.0.1.0.0.2"},
+		{"loopback", "::1", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0"},
+		{"test address", "::FFFF:7F00:2", "2.0.0.0.0.0.f.7.f.f.f.f.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0"},
+		{"invalid", "not an ip", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reverseIP6(tt.ip); got != tt.want {
+				t.Errorf("reverseIP6() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecker_lookupIP4(t *testing.T) {
+	r := newFakeList("bl.example.com")
+	r.hosts["1.2.0.192.bl.example.com"] = []string{"127.0.0.2", "127.0.0.10"}
+	r.txt["1.2.0.192.bl.example.com"] = []string{"Listed, see https://bl.example.com/192.0.2.1"}
+	r.hosts["2.2.0.192.bl.example.com"] = []string{"192.0.2.2"}
+
+	c := NewChecker(nil)
+	c.Resolver = r
+	list := &ListItem{Address: "bl.example.com"}
+
+	l, err := c.lookupIP4(context.Background(), "192.0.2.1", list)
+	if err != nil {
+		t.Fatalf("lookupIP4() error = %v", err)
+	}
+	if len(l.codes) != 2 || l.codes[1] != "127.0.0.10" {
+		t.Errorf("lookupIP4() codes = %v", l.codes)
+	}
+	if len(l.txt) != 1 {
+		t.Errorf("lookupIP4() txt = %v", l.txt)
+	}
+
+	if _, err := c.lookupIP4(context.Background(), "192.0.2.2", list); err != ErrWrongResponse {
+		t.Errorf("lookupIP4() error = %v, want %v", err, ErrWrongResponse)
+	}
+
+	list.BlockedCodes = map[string]string{"127.255.255.254": "Query via public resolver"}
+	r.hosts["4.2.0.192.bl.example.com"] = []string{"127.255.255.254"}
+	if l, err := c.lookupIP4(context.Background(), "192.0.2.4", list); !errors.Is(err, ErrQueryBlocked) || err.(*blockedError).codes[0] != "127.255.255.254" {
+		t.Errorf("lookupIP4() = %v, %v, want %v", l, err, ErrQueryBlocked)
+	}
+
+	if l, err := c.lookupIP4(context.Background(), "192.0.2.3", list); l != nil || err == nil {
+		t.Errorf("lookupIP4() = %v, %v, want not found", l, err)
+	}
+
+	if _, err := c.lookupIP4(context.Background(), "192.0.2.1", &ListItem{Address: "broken.example.com"}); err != ErrRBLPositiveFail {
+		t.Errorf("lookupIP4() error = %v, want %v", err, ErrRBLPositiveFail)
+	}
+}
+
+func TestChecker_CheckIP4_invalid(t *testing.T) {
+	c := NewChecker([]*ListItem{{Address: "bl.example.com", IP4: true, Blacklist: true}})
+	c.Resolver = newFakeList("bl.example.com")
+
+	report := c.CheckIP4(false, "192.0.2")
+	if report.Failures != 1 || report.Results[0].Err != ErrInvalidAddress {
+		t.Errorf("CheckIP4() = %+v, want failure", report.Results[0])
+	}
+}
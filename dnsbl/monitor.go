@@ -0,0 +1,154 @@
+package dnsbl
+
+import (
+	"math/rand"
+	"time"
+)
+
+// DefaultInterval is the time between checks used when Monitor.Interval is not set
+const DefaultInterval = 30 * time.Minute
+
+// EventType is the kind of change in the listing of a target
+type EventType int
+
+const (
+	// EventListed means the target was added to a list
+	EventListed EventType = iota
+	// EventDelisted means the target was removed from a list
+	EventDelisted
+)
+
+func (e EventType) String() string {
+	switch e {
+	case EventListed:
+		return "LISTED"
+	case EventDelisted:
+		return "DELISTED"
+	}
+
+	return "UNKNOWN"
+}
+
+// Event is a change in the listing of a target on a single list
+type Event struct {
+	// Type is the kind of change
+	Type EventType
+	// Target is the IP address or domain whose listing changed
+	Target string
+	// List is the list the target was added to or removed from
+	List *ListItem
+	// Time is when the check that noticed the change started
+	Time time.Time
+	// ReturnCodes are the decoded answers of the list, if the target was listed
+	ReturnCodes []ReturnCode
+	// TXT are the TXT records of the list, if the target was listed
+	TXT []string
+}
+
+// Monitor periodically checks a set of targets and reports listings that appear or disappear
+type Monitor struct {
+	// Checker is used for all checks
+	Checker *Checker
+	// Targets are the IP addresses and domains to check
+	Targets []string
+	// Whitelist is true if whitelists are checked instead of blacklists
+	Whitelist bool
+	// Interval is the time between the start of two checks
+	Interval time.Duration
+	// Jitter is the largest random delay added to every Interval, so that
+	// several monitors don't query the lists at the same time
+	Jitter time.Duration
+
+	// listed holds the addresses of the lists each target is currently listed on
+	listed map[string]map[string]bool
+	rand   *rand.Rand
+}
+
+// NewMonitor returns a Monitor that checks `targets` with `checker`
+func NewMonitor(checker *Checker, targets []string) *Monitor {
+	return &Monitor{
+		Checker:  checker,
+		Targets:  targets,
+		Interval: DefaultInterval,
+	}
+}
+
+// Check checks all targets once and returns the report and the changes since the
+// previous check. The first check reports every existing listing as listed.
+// Timeouts, failures and other errors don't change the state of a target, so a
+// list that is temporarily unavailable doesn't cause a delisting and relisting.
+func (m *Monitor) Check() (*BatchReport, []Event) {
+	if m.listed == nil {
+		m.listed = map[string]map[string]bool{}
+	}
+
+	// lists' health can change while the monitor is running
+	m.Checker.ResetHealth()
+	batch := m.Checker.CheckBatch(m.Whitelist, m.Targets)
+
+	events := []Event{}
+	for _, report := range batch.Reports {
+		listed, ok := m.listed[report.Target]
+		if !ok {
+			listed = map[string]bool{}
+			m.listed[report.Target] = listed
+		}
+
+		for _, res := range report.Results {
+			address := res.List.Address
+			switch {
+			case res.Status == StatusHit && !listed[address]:
+				listed[address] = true
+				events = append(events, Event{
+					Type:        EventListed,
+					Target:      report.Target,
+					List:        res.List,
+					Time:        report.Time,
+					ReturnCodes: res.ReturnCodes,
+					TXT:         res.TXT,
+				})
+			case res.Status == StatusMiss && listed[address]:
+				delete(listed, address)
+				events = append(events, Event{
+					Type:   EventDelisted,
+					Target: report.Target,
+					List:   res.List,
+					Time:   report.Time,
+				})
+			}
+		}
+	}
+
+	return batch, events
+}
+
+// Run checks all targets every Interval plus a random delay of up to Jitter, until
+// `stop` is closed. `handle` is called with the report and the changes of every check.
+func (m *Monitor) Run(stop <-chan struct{}, handle func(*BatchReport, []Event)) {
+	for {
+		handle(m.Check())
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(m.nextDelay()):
+		}
+	}
+}
+
+// nextDelay returns the time to wait until the next check
+func (m *Monitor) nextDelay() time.Duration {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if m.Jitter <= 0 {
+		return interval
+	}
+
+	if m.rand == nil {
+		m.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	return interval + time.Duration(m.rand.Int63n(int64(m.Jitter)))
+}
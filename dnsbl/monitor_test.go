@@ -0,0 +1,66 @@
+package dnsbl
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMonitor_Check(t *testing.T) {
+	r := newFakeList("bl.example.com")
+	c := NewChecker([]*ListItem{{Address: "bl.example.com", IP4: true, Blacklist: true}})
+	c.Resolver = r
+	m := NewMonitor(c, []string{"192.0.2.1", "192.0.2.2"})
+
+	timeout := &net.DNSError{Err: "i/o timeout", Name: "1.2.0.192.bl.example.com", IsTimeout: true}
+	rounds := []struct {
+		name  string
+		hosts map[string][]string
+		errs  map[string]error
+		want  []string
+	}{
+		{"already listed", map[string][]string{"1.2.0.192.bl.example.com": {"127.0.0.2"}}, nil, []string{"LISTED 192.0.2.1"}},
+		{"unchanged", map[string][]string{"1.2.0.192.bl.example.com": {"127.0.0.2"}}, nil, []string{}},
+		{"timeout keeps the listing", nil, map[string]error{"1.2.0.192.bl.example.com": timeout}, []string{}},
+		{"delisted and listed", map[string][]string{"2.2.0.192.bl.example.com": {"127.0.0.2"}}, nil, []string{"DELISTED 192.0.2.1", "LISTED 192.0.2.2"}},
+	}
+	for _, round := range rounds {
+		r.hosts = map[string][]string{"2.0.0.127.bl.example.com": {"127.0.0.2"}}
+		for k, v := range round.hosts {
+			r.hosts[k] = v
+		}
+		r.errs = round.errs
+
+		_, events := m.Check()
+
+		got := []string{}
+		for _, e := range events {
+			if e.List.Address != "bl.example.com" {
+				t.Errorf("%v: event list = %v", round.name, e.List.Address)
+			}
+			got = append(got, e.Type.String()+" "+e.Target)
+		}
+		if len(got) != len(round.want) {
+			t.Errorf("%v: Check() events = %v, want %v", round.name, got, round.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != round.want[i] {
+				t.Errorf("%v: Check() events = %v, want %v", round.name, got, round.want)
+			}
+		}
+	}
+}
+
+func TestMonitor_nextDelay(t *testing.T) {
+	m := &Monitor{Interval: time.Minute, Jitter: 10 * time.Second}
+	for i := 0; i < 100; i++ {
+		if d := m.nextDelay(); d < time.Minute || d >= time.Minute+10*time.Second {
+			t.Fatalf("nextDelay() = %v, want between 1m and 1m10s", d)
+		}
+	}
+
+	if d := (&Monitor{}).nextDelay(); d != DefaultInterval {
+		t.Errorf("nextDelay() without interval = %v, want %v", d, DefaultInterval)
+	}
+}
@@ -0,0 +1,108 @@
+package dnsbl
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket that allows `rate` queries per second on
+// average and bursts of up to `burst` queries
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait blocks until a query may be sent. It returns the error of `ctx` if it
+// is done first, in which case the query must not be sent.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	// the token is taken now, so waiting queries are served in order
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// waitRate blocks until the query of `name` is allowed by RateLimit and OperatorRateLimit
+func (c *Checker) waitRate(ctx context.Context, name string) error {
+	var global, operator *rateLimiter
+
+	c.rateMu.Lock()
+	if c.RateLimit > 0 {
+		if c.rateLimiter == nil {
+			c.rateLimiter = newRateLimiter(c.RateLimit, 1)
+		}
+		global = c.rateLimiter
+	}
+	if c.OperatorRateLimit > 0 {
+		if c.operatorLimiters == nil {
+			c.operatorLimiters = map[string]*rateLimiter{}
+		}
+		key := operatorOf(name)
+		if c.operatorLimiters[key] == nil {
+			c.operatorLimiters[key] = newRateLimiter(c.OperatorRateLimit, 1)
+		}
+		operator = c.operatorLimiters[key]
+	}
+	c.rateMu.Unlock()
+
+	// the operator's budget is used first, so a slow operator doesn't hold up the global budget
+	if operator != nil {
+		if err := operator.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if global != nil {
+		return global.wait(ctx)
+	}
+
+	return nil
+}
+
+// secondLevels are labels that are used below country code TLDs like
+// .co.uk, so the operator of rbl.fasthosts.co.uk is fasthosts.co.uk
+var secondLevels = map[string]bool{"ac": true, "co": true, "com": true, "edu": true, "gov": true, "ne": true, "net": true, "or": true, "org": true}
+
+// operatorOf returns the registered domain of the query name `name`, e.g.
+// sorbs.net for 2.0.0.127.dnsbl.sorbs.net. Lists with the same registered
+// domain are run by the same operator.
+func operatorOf(name string) string {
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(name), "."), ".")
+	n := 2
+	if len(labels) >= 3 && len(labels[len(labels)-1]) == 2 && secondLevels[labels[len(labels)-2]] {
+		n = 3
+	}
+	if len(labels) < n {
+		n = len(labels)
+	}
+
+	return strings.Join(labels[len(labels)-n:], ".")
+}
@@ -0,0 +1,67 @@
+package dnsbl
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_rateLimiter(t *testing.T) {
+	l := newRateLimiter(100, 1)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatalf("wait() error = %v", err)
+		}
+	}
+	// the first query uses the burst, the other four wait 10ms each
+	if d := time.Since(start); d < 35*time.Millisecond {
+		t.Errorf("5 queries at 100/s took %v, want at least 40ms", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l = newRateLimiter(1, 1)
+	l.wait(ctx)
+	if err := l.wait(ctx); err != context.Canceled {
+		t.Errorf("wait() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestChecker_waitRate(t *testing.T) {
+	c := NewChecker(nil)
+	c.OperatorRateLimit = 1
+
+	// the first query of every operator uses its burst
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	for _, name := range []string{"2.0.0.127.dnsbl.sorbs.net", "2.0.0.127.zen.spamhaus.org"} {
+		if err := c.waitRate(ctx, name); err != nil {
+			t.Errorf("waitRate(%v) error = %v", name, err)
+		}
+	}
+	if err := c.waitRate(ctx, "2.0.0.127.spam.dnsbl.sorbs.net"); err != context.DeadlineExceeded {
+		t.Errorf("waitRate() error = %v, want sorbs.net budget to be used up", err)
+	}
+}
+
+func Test_operatorOf(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"2.0.0.127.dnsbl.sorbs.net", "sorbs.net"},
+		{"2.0.0.127.spam.dnsbl.sorbs.net.", "sorbs.net"},
+		{"2.0.0.127.ZEN.Spamhaus.org", "spamhaus.org"},
+		{"2.0.0.127.rbl.fasthosts.co.uk", "fasthosts.co.uk"},
+		{"2.0.0.127.spamlist.or.kr", "spamlist.or.kr"},
+		{"example", "example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := operatorOf(tt.name); got != tt.want {
+				t.Errorf("operatorOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package dnsbl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// Resolver looks up DNS records. *net.Resolver implements it.
+type Resolver interface {
+	// LookupHost returns the addresses of `host`
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	// LookupTXT returns the TXT records of `name`
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// NewResolver returns a Resolver that sends all queries to the recursive
+// server at `server`, instead of the system resolver. `server` is in
+// host:port form. Port 53 is used if it's omitted. If `server` is a tls:// or
+// https:// URL, queries are sent over DNS-over-TLS or DNS-over-HTTPS by a Client.
+func NewResolver(server string) Resolver {
+	if strings.HasPrefix(server, "tls://") || strings.HasPrefix(server, "https://") {
+		return NewClient(server)
+	}
+	server = withDefaultPort(server, "53")
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			d := net.Dialer{}
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// retryResolver limits every query of a Resolver to `timeout` and repeats
+// queries that timed out or failed temporarily up to `retries` times. If `wait`
+// is set, it is called before every attempt and its time doesn't count towards `timeout`.
+type retryResolver struct {
+	r       Resolver
+	timeout time.Duration
+	retries int
+	wait    func(ctx context.Context, name string) error
+}
+
+func (r *retryResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	var addrs []string
+	err := r.retry(ctx, host, func(ctx context.Context) (err error) {
+		addrs, err = r.r.LookupHost(ctx, host)
+		return err
+	})
+
+	return addrs, err
+}
+
+func (r *retryResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	var txt []string
+	err := r.retry(ctx, name, func(ctx context.Context) (err error) {
+		txt, err = r.r.LookupTXT(ctx, name)
+		return err
+	})
+
+	return txt, err
+}
+
+// retry calls `query` of `name` until it succeeds, fails permanently, runs out of retries or `ctx` is done
+func (r *retryResolver) retry(ctx context.Context, name string, query func(context.Context) error) error {
+	var err error
+	for attempt := 0; attempt <= r.retries; attempt++ {
+		if r.wait != nil {
+			if err := r.wait(ctx, name); err != nil {
+				return err
+			}
+		}
+
+		qctx, cancel := ctx, context.CancelFunc(func() {})
+		if r.timeout > 0 {
+			qctx, cancel = context.WithTimeout(ctx, r.timeout)
+		}
+		err = query(qctx)
+		cancel()
+
+		if err == nil || ctx.Err() != nil || !isRetryable(err) {
+			break
+		}
+	}
+
+	return err
+}
+
+// classifyError returns the status of a query that failed with `err`.
+// Client reports the rcode in a *ResponseError. *net.Resolver reports NXDOMAIN as IsNotFound and SERVFAIL as IsTemporary.
+// It does not report other rcodes, so they are a failure.
+func classifyError(err error) Status {
+	var respErr *ResponseError
+	var dnsErr *net.DNSError
+	switch {
+	case isTimeout(err):
+		return StatusTimeout
+	case errors.As(err, &respErr):
+		return classifyRcode(respErr.Rcode)
+	case !errors.As(err, &dnsErr):
+		return StatusFailure
+	case dnsErr.IsNotFound:
+		return StatusMiss
+	case dnsErr.IsTemporary:
+		return StatusServFail
+	}
+
+	return StatusFailure
+}
+
+// classifyRcode returns the status of a query that was answered with `rcode` and no records
+func classifyRcode(rcode Rcode) Status {
+	switch rcode {
+	case RcodeSuccess, RcodeNameError:
+		return StatusMiss
+	case RcodeServerFailure:
+		return StatusServFail
+	case RcodeRefused:
+		return StatusRefused
+	}
+
+	return StatusFailure
+}
+
+// isNotFound returns true if `err` means that the queried name has no records
+func isNotFound(err error) bool {
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return classifyRcode(respErr.Rcode) == StatusMiss
+	}
+
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// isTimeout returns true if `err` means that a query did not get an answer in time
+func isTimeout(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// isRetryable returns true if repeating the query that failed with `err` could succeed
+func isRetryable(err error) bool {
+	var respErr *ResponseError
+	if errors.As(err, &respErr) && respErr.Rcode == RcodeServerFailure {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsTemporary {
+		return true
+	}
+
+	return isTimeout(err)
+}
@@ -0,0 +1,134 @@
+package dnsbl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeResolver answers queries from static maps of records and errors
+type fakeResolver struct {
+	hosts map[string][]string
+	txt   map[string][]string
+	errs  map[string]error
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if err, ok := r.errs[host]; ok {
+		return nil, err
+	}
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if txt, ok := r.txt[name]; ok {
+		return txt, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+// newFakeList returns a fakeResolver with a healthy IPv4 list at `list`
+func newFakeList(list string) *fakeResolver {
+	return &fakeResolver{
+		hosts: map[string][]string{
+			"2.0.0.127." + list: {"127.0.0.2"},
+		},
+		txt:  map[string][]string{},
+		errs: map[string]error{},
+	}
+}
+
+// flakyResolver times out the first `failures` queries of every name
+type flakyResolver struct {
+	*fakeResolver
+	failures int
+	queries  map[string]int
+}
+
+func (r *flakyResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.queries[host]++
+	if r.queries[host] <= r.failures {
+		return nil, &net.DNSError{Err: "i/o timeout", Name: host, IsTimeout: true}
+	}
+	return r.fakeResolver.LookupHost(ctx, host)
+}
+
+func Test_retryResolver(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		retries  int
+		host     string
+		wantErr  bool
+		want     int
+	}{
+		{"answer after retry", 1, 1, "2.0.0.127.bl.example.com", false, 2},
+		{"out of retries", 2, 1, "2.0.0.127.bl.example.com", true, 2},
+		{"not found is not retried", 0, 3, "1.0.0.127.bl.example.com", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flaky := &flakyResolver{fakeResolver: newFakeList("bl.example.com"), failures: tt.failures, queries: map[string]int{}}
+			r := &retryResolver{r: flaky, timeout: time.Second, retries: tt.retries}
+
+			_, err := r.LookupHost(context.Background(), tt.host)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LookupHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if flaky.queries[tt.host] != tt.want {
+				t.Errorf("LookupHost() sent %v queries, want %v", flaky.queries[tt.host], tt.want)
+			}
+		})
+	}
+}
+
+func Test_isTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"DNS timeout", &net.DNSError{Err: "i/o timeout", IsTimeout: true}, true},
+		{"deadline", context.DeadlineExceeded, true},
+		{"not found", &net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{"timeout text only", errors.New("read udp 127.0.0.1:53: i/o timeout"), false},
+		{"canceled", context.Canceled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTimeout(tt.err); got != tt.want {
+				t.Errorf("isTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_classifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Status
+	}{
+		{"NXDOMAIN", &net.DNSError{Err: "no such host", IsNotFound: true}, StatusMiss},
+		{"timeout", &net.DNSError{Err: "i/o timeout", IsTimeout: true, IsTemporary: true}, StatusTimeout},
+		{"deadline", context.DeadlineExceeded, StatusTimeout},
+		{"SERVFAIL", &net.DNSError{Err: "server misbehaving", IsTemporary: true}, StatusServFail},
+		{"REFUSED", &ResponseError{Name: "bl.example.com", Rcode: RcodeRefused}, StatusRefused},
+		{"other rcode via net.Resolver", &net.DNSError{Err: "server misbehaving"}, StatusFailure},
+		{"other DNS error", &net.DNSError{Err: "lame referral"}, StatusFailure},
+		{"no such host text only", errors.New("lookup bl.example.com: no such host"), StatusFailure},
+		{"wrong response", ErrWrongResponse, StatusFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
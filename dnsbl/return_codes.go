@@ -0,0 +1,211 @@
+package dnsbl
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+var spamhausZENCodes = map[string]string{
+	"127.0.0.2":  "SBL - Spamhaus SBL data",
+	"127.0.0.3":  "SBL - Spamhaus SBL CSS data",
+	"127.0.0.4":  "XBL - CBL data",
+	"127.0.0.5":  "XBL",
+	"127.0.0.6":  "XBL",
+	"127.0.0.7":  "XBL",
+	"127.0.0.9":  "SBL - Spamhaus DROP/EDROP data",
+	"127.0.0.10": "PBL - ISP maintained",
+	"127.0.0.11": "PBL - Spamhaus maintained",
+}
+
+var sorbsCodes = map[string]string{
+	"127.0.0.2":  "HTTP proxy",
+	"127.0.0.3":  "SOCKS proxy",
+	"127.0.0.4":  "Other proxy",
+	"127.0.0.5":  "Open SMTP relay",
+	"127.0.0.6":  "Spam source (last 48 hours)",
+	"127.0.0.7":  "Vulnerable web server",
+	"127.0.0.8":  "Hosts demanding never to be tested",
+	"127.0.0.9":  "Hijacked network",
+	"127.0.0.10": "Dynamic IP address",
+	"127.0.0.11": "Domain pointing to bad addresses",
+	"127.0.0.12": "Domain indicating no email sender",
+	"127.0.0.14": "Server without email service",
+}
+
+// returnCodes maps a list's address to the meaning of its return codes
+var returnCodes = map[string]map[string]string{
+	"zen.spamhaus.org": spamhausZENCodes,
+	"sbl.spamhaus.org": {
+		"127.0.0.2": spamhausZENCodes["127.0.0.2"],
+		"127.0.0.3": spamhausZENCodes["127.0.0.3"],
+		"127.0.0.9": spamhausZENCodes["127.0.0.9"],
+	},
+	"xbl.spamhaus.org": {
+		"127.0.0.4": spamhausZENCodes["127.0.0.4"],
+		"127.0.0.5": spamhausZENCodes["127.0.0.5"],
+		"127.0.0.6": spamhausZENCodes["127.0.0.6"],
+		"127.0.0.7": spamhausZENCodes["127.0.0.7"],
+	},
+	"pbl.spamhaus.org": {
+		"127.0.0.10": spamhausZENCodes["127.0.0.10"],
+		"127.0.0.11": spamhausZENCodes["127.0.0.11"],
+	},
+	"sbl-xbl.spamhaus.org": {
+		"127.0.0.2": spamhausZENCodes["127.0.0.2"],
+		"127.0.0.3": spamhausZENCodes["127.0.0.3"],
+		"127.0.0.4": spamhausZENCodes["127.0.0.4"],
+		"127.0.0.5": spamhausZENCodes["127.0.0.5"],
+		"127.0.0.6": spamhausZENCodes["127.0.0.6"],
+		"127.0.0.7": spamhausZENCodes["127.0.0.7"],
+		"127.0.0.9": spamhausZENCodes["127.0.0.9"],
+	},
+	"dbl.spamhaus.org": {
+		"127.0.1.2":   "Spam domain",
+		"127.0.1.4":   "Phishing domain",
+		"127.0.1.5":   "Malware domain",
+		"127.0.1.6":   "Botnet C&C domain",
+		"127.0.1.102": "Abused legit spam",
+		"127.0.1.103": "Abused spammed redirector domain",
+		"127.0.1.104": "Abused legit phish",
+		"127.0.1.105": "Abused legit malware",
+		"127.0.1.106": "Abused legit botnet C&C",
+	},
+	"dnsbl.sorbs.net": sorbsCodes,
+	"rhsbl.sorbs.net": sorbsCodes,
+	"multi.uribl.com": {
+		"127.0.0.2": "black",
+		"127.0.0.4": "grey",
+		"127.0.0.8": "red",
+	},
+	"hostkarma.junkemailfilter.com": {
+		"127.0.0.1": "White - good sender",
+		"127.0.0.2": "Black - spam sender",
+		"127.0.0.3": "Yellow - mixed good and spam sender",
+		"127.0.0.4": "Brown - mostly spam sender",
+		"127.0.0.5": "NOBL - not to be blacklisted",
+	},
+	"rep.mailspike.net": {
+		"127.0.0.10": "L5 - worst possible reputation",
+		"127.0.0.11": "L4 - very bad reputation",
+		"127.0.0.12": "L3 - bad reputation",
+		"127.0.0.13": "L2 - suspicious reputation",
+		"127.0.0.14": "L1 - neutral reputation, probably spam",
+		"127.0.0.15": "H0 - neutral reputation",
+		"127.0.0.16": "H1 - neutral reputation, probably legit",
+		"127.0.0.17": "H2 - good reputation",
+		"127.0.0.18": "H3 - very good reputation",
+		"127.0.0.19": "H4 - excellent reputation",
+		"127.0.0.20": "H5 - best possible reputation",
+	},
+	"multi.surbl.org": {
+		"127.0.0.8":   "PH - phishing",
+		"127.0.0.16":  "MW - malware",
+		"127.0.0.64":  "ABUSE - spam and abuse",
+		"127.0.0.128": "CR - cracked sites",
+	},
+}
+
+var spamhausBlockedCodes = map[string]string{
+	"127.255.255.252": "Typing error in DNSBL name",
+	"127.255.255.254": "Query via public/open resolver",
+	"127.255.255.255": "Excessive number of queries",
+}
+
+var uriblBlockedCodes = map[string]string{
+	"127.0.0.1": "Query refused, possibly via public resolver or excessive number of queries",
+}
+
+// blockedCodes maps a list's address to the codes it returns when it refuses a query
+var blockedCodes = map[string]map[string]string{
+	"zen.spamhaus.org":        spamhausBlockedCodes,
+	"sbl.spamhaus.org":        spamhausBlockedCodes,
+	"xbl.spamhaus.org":        spamhausBlockedCodes,
+	"pbl.spamhaus.org":        spamhausBlockedCodes,
+	"sbl-xbl.spamhaus.org":    spamhausBlockedCodes,
+	"dbl.spamhaus.org":        spamhausBlockedCodes,
+	"swl.spamhaus.org":        spamhausBlockedCodes,
+	"_vouch.dwl.spamhaus.org": spamhausBlockedCodes,
+	"multi.surbl.org": {
+		"127.0.0.1": "Query blocked, possibly via public resolver or excessive number of queries",
+	},
+	"multi.uribl.com": uriblBlockedCodes,
+	"black.uribl.com": uriblBlockedCodes,
+	"grey.uribl.com":  uriblBlockedCodes,
+	"red.uribl.com":   uriblBlockedCodes,
+	"white.uribl.com": uriblBlockedCodes,
+}
+
+// blacklistCodes maps a combined list's address to the codes that mean the target is listed
+var blacklistCodes = map[string][]string{
+	"hostkarma.junkemailfilter.com": {"127.0.0.2", "127.0.0.4"},
+	"rep.mailspike.net":             {"127.0.0.10", "127.0.0.11", "127.0.0.12", "127.0.0.13"},
+}
+
+// whitelistCodes maps a combined list's address to the codes that mean the target is whitelisted
+var whitelistCodes = map[string][]string{
+	"hostkarma.junkemailfilter.com": {"127.0.0.1"},
+	"rep.mailspike.net":             {"127.0.0.17", "127.0.0.18", "127.0.0.19", "127.0.0.20"},
+}
+
+// bitmaskLists are lists that combine several return codes into a single answer
+var bitmaskLists = []string{"multi.uribl.com", "multi.surbl.org"}
+
+// ReturnCode is a single answer returned by a list
+type ReturnCode struct {
+	// Code is the returned address, e.g. 127.0.0.2
+	Code string `json:"code"`
+	// Meaning is the decoded listing category. Empty if the list doesn't document the code.
+	Meaning string `json:"meaning,omitempty"`
+}
+
+// decodeReturnCodes returns the meaning of every code in `codes` according to `list`
+func decodeReturnCodes(list *ListItem, codes []string) []ReturnCode {
+	decoded := make([]ReturnCode, 0, len(codes))
+	for _, code := range codes {
+		decoded = append(decoded, ReturnCode{
+			Code:    code,
+			Meaning: list.decodeReturnCode(code),
+		})
+	}
+
+	return decoded
+}
+
+// isBlocked returns true if any of `codes` means that the list refused the query
+func (l *ListItem) isBlocked(codes []string) bool {
+	for _, code := range codes {
+		if _, ok := l.BlockedCodes[code]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeReturnCode returns the meaning of `code`, or an empty string if it is unknown
+func (l *ListItem) decodeReturnCode(code string) string {
+	if meaning, ok := l.BlockedCodes[code]; ok {
+		return meaning
+	}
+
+	if meaning, ok := l.ReturnCodes[code]; ok || !l.ReturnCodesBitmask {
+		return meaning
+	}
+
+	ip := net.ParseIP(code).To4()
+	if ip == nil {
+		return ""
+	}
+
+	meanings := []string{}
+	for k, v := range l.ReturnCodes {
+		bit := net.ParseIP(k).To4()
+		if bit != nil && ip[3]&bit[3] != 0 {
+			meanings = append(meanings, v)
+		}
+	}
+	sort.Strings(meanings)
+
+	return strings.Join(meanings, ", ")
+}
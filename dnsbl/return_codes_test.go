@@ -0,0 +1,28 @@
+package dnsbl
+
+import "testing"
+
+func TestListItem_decodeReturnCode(t *testing.T) {
+	zen := &ListItem{ReturnCodes: returnCodes["zen.spamhaus.org"]}
+	surbl := &ListItem{ReturnCodes: returnCodes["multi.surbl.org"], ReturnCodesBitmask: true}
+
+	tests := []struct {
+		name string
+		list *ListItem
+		code string
+		want string
+	}{
+		{"exact code", zen, "127.0.0.10", "PBL - ISP maintained"},
+		{"unknown code", zen, "127.0.0.99", ""},
+		{"no codes", &ListItem{}, "127.0.0.2", ""},
+		{"bitmask single", surbl, "127.0.0.16", "MW - malware"},
+		{"bitmask combined", surbl, "127.0.0.24", "MW - malware, PH - phishing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.list.decodeReturnCode(tt.code); got != tt.want {
+				t.Errorf("decodeReturnCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package dnsbl
+
+// DefaultWeight is the weight of lists that don't have one
+const DefaultWeight = 1
+
+// listWeights maps the addresses of well known lists to their weight. A hit on
+// a list that most receivers use is worth more than a hit on an obscure list.
+var listWeights = map[string]float64{
+	"zen.spamhaus.org":        10,
+	"sbl.spamhaus.org":        10,
+	"sbl-xbl.spamhaus.org":    10,
+	"xbl.spamhaus.org":        8,
+	"pbl.spamhaus.org":        3,
+	"dbl.spamhaus.org":        10,
+	"b.barracudacentral.org":  8,
+	"bb.barracudacentral.org": 8,
+	"bl.spamcop.net":          8,
+	"cbl.abuseat.org":         8,
+	"multi.uribl.com":         8,
+	"black.uribl.com":         8,
+	"multi.surbl.org":         8,
+	"dnsbl.sorbs.net":         5,
+	"psbl.surriel.com":        5,
+	"bl.mailspike.net":        5,
+	"ix.dnsbl.manitu.net":     5,
+	"dnsbl-1.uceprotect.net":  3,
+	"dnsbl-2.uceprotect.net":  2,
+}
+
+// Severity is how serious the listings of a target are
+type Severity int
+
+// Severities have the same values as Nagios exit codes
+const (
+	// SeverityOK means the score is below the warning threshold
+	SeverityOK Severity = iota
+	// SeverityWarning means the score reached the warning threshold
+	SeverityWarning
+	// SeverityCritical means the score reached the critical threshold
+	SeverityCritical
+	// SeverityUnknown means the target could not be checked reliably
+	SeverityUnknown
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityOK:
+		return "OK"
+	case SeverityWarning:
+		return "WARNING"
+	case SeverityCritical:
+		return "CRITICAL"
+	}
+
+	return "UNKNOWN"
+}
+
+// EffectiveWeight returns the weight of the list, or DefaultWeight if it doesn't have one
+func (l *ListItem) EffectiveWeight() float64 {
+	if l.Weight > 0 {
+		return l.Weight
+	}
+
+	return DefaultWeight
+}
+
+// Severity returns the severity of the report's score for the `warning` and `critical` thresholds
+func (r *Report) Severity(warning, critical float64) Severity {
+	return ThresholdSeverity(r.Score, warning, critical)
+}
+
+// ThresholdSeverity returns the severity of `value`, e.g. a score or a number of hits,
+// for the `warning` and `critical` thresholds. A value of 0 is always OK.
+func ThresholdSeverity(value, warning, critical float64) Severity {
+	switch {
+	case value > 0 && value >= critical:
+		return SeverityCritical
+	case value > 0 && value >= warning:
+		return SeverityWarning
+	}
+
+	return SeverityOK
+}
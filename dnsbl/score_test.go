@@ -0,0 +1,32 @@
+package dnsbl
+
+import "testing"
+
+func TestReport_Severity(t *testing.T) {
+	obscure := &ListItem{Address: "bl.example.com"}
+	zen := &ListItem{Address: "zen.spamhaus.org", Weight: 10}
+
+	tests := []struct {
+		name      string
+		results   []*Result
+		wantScore float64
+		want      Severity
+	}{
+		{"clean", []*Result{{List: obscure}, {List: zen}}, 0, SeverityOK},
+		{"obscure list", []*Result{{List: obscure, Status: StatusHit}, {List: zen}}, 1, SeverityWarning},
+		{"major list", []*Result{{List: obscure}, {List: zen, Status: StatusHit}}, 10, SeverityCritical},
+		{"both", []*Result{{List: obscure, Status: StatusHit}, {List: zen, Status: StatusHit}}, 11, SeverityCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Report{Results: tt.results}
+			r.count()
+			if r.Score != tt.wantScore {
+				t.Errorf("Score = %v, want %v", r.Score, tt.wantScore)
+			}
+			if got := r.Severity(1, 10); got != tt.want {
+				t.Errorf("Severity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+// exporter holds the metrics of the latest check of all targets and counters of all checks
+type exporter struct {
+	mu       sync.Mutex
+	batch    *dnsbl.BatchReport
+	runs     int
+	timeouts map[string]int
+	failures map[string]int
+}
+
+// runExporter checks all targets from the exporter file every --interval and serves
+// the results as Prometheus metrics on --listen
+func runExporter(checker *dnsbl.Checker) {
+	f, err := os.Open(*cfgExporterFile)
+	if err != nil {
+		app.Fatalf("%v", err)
+	}
+	targets, err := readTargets(f, *cfgMaxRange)
+	f.Close()
+	if err != nil {
+		app.Fatalf("%v", err)
+	}
+	if len(targets) == 0 {
+		app.Fatalf("%v does not contain any targets", *cfgExporterFile)
+	}
+
+	m := dnsbl.NewMonitor(checker, targets)
+	m.Whitelist = *cfgWhitelist
+	m.Interval = *cfgExportInterval
+	m.Jitter = *cfgExportJitter
+
+	e := &exporter{}
+	go m.Run(nil, func(batch *dnsbl.BatchReport, events []dnsbl.Event) {
+		saveHealthCache(checker)
+		e.update(batch)
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html><body><h1>dnsbl_checker</h1><a href=\"/metrics\">Metrics</a></body></html>\n")
+	})
+
+	if err := http.ListenAndServe(*cfgListen, mux); err != nil {
+		app.Fatalf("%v", err)
+	}
+}
+
+// update replaces the latest check with `batch` and adds its timeouts and failures to the counters
+func (e *exporter) update(batch *dnsbl.BatchReport) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.timeouts == nil {
+		e.timeouts = map[string]int{}
+		e.failures = map[string]int{}
+	}
+
+	e.batch = batch
+	e.runs++
+	for _, report := range batch.Reports {
+		for _, res := range report.Results {
+			switch res.Status {
+			case dnsbl.StatusTimeout:
+				e.timeouts[res.List.Address]++
+			case dnsbl.StatusFailure, dnsbl.StatusServFail, dnsbl.StatusRefused:
+				e.failures[res.List.Address]++
+			}
+		}
+	}
+}
+
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.writeMetrics(w)
+}
+
+// writeMetrics writes all metrics to `w` in the Prometheus text exposition format
+func (e *exporter) writeMetrics(w io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	writeMetric(w, "dnsbl_checks_total", "counter", "Number of completed checks of all targets.")
+	fmt.Fprintf(w, "dnsbl_checks_total %v\n", e.runs)
+	writeCounters(w, "dnsbl_list_timeouts_total", "Number of queries to a DNSBL that timed out.", e.timeouts)
+	writeCounters(w, "dnsbl_list_failures_total", "Number of queries to a DNSBL that failed.", e.failures)
+
+	if e.batch == nil {
+		return
+	}
+
+	writeMetric(w, "dnsbl_last_check_timestamp_seconds", "gauge", "Time the latest check of all targets started.")
+	fmt.Fprintf(w, "dnsbl_last_check_timestamp_seconds %v\n", e.batch.Time.Unix())
+	writeMetric(w, "dnsbl_last_check_duration_seconds", "gauge", "How long the latest check of all targets took.")
+	fmt.Fprintf(w, "dnsbl_last_check_duration_seconds %v\n", e.batch.Duration.Seconds())
+
+	writeMetric(w, "dnsbl_listed", "gauge", "1 if the target is listed on the DNSBL, 0 if it is not. Missing if the DNSBL did not answer.")
+	healthy := map[string]bool{}
+	lists := []string{}
+	for _, report := range e.batch.Reports {
+		for _, res := range report.Results {
+			address := res.List.Address
+			if _, ok := healthy[address]; !ok {
+				healthy[address] = true
+				lists = append(lists, address)
+			}
+
+			switch res.Status {
+			case dnsbl.StatusHit:
+				fmt.Fprintf(w, "dnsbl_listed{target=%v,list=%v} 1\n", quoteLabel(report.Target), quoteLabel(address))
+			case dnsbl.StatusMiss:
+				fmt.Fprintf(w, "dnsbl_listed{target=%v,list=%v} 0\n", quoteLabel(report.Target), quoteLabel(address))
+			case dnsbl.StatusUnhealthy, dnsbl.StatusBlocked:
+				healthy[address] = false
+			}
+		}
+	}
+
+	writeMetric(w, "dnsbl_list_healthy", "gauge", "1 if the DNSBL passed its health check and answered queries, 0 otherwise.")
+	sort.Strings(lists)
+	for _, address := range lists {
+		value := 0
+		if healthy[address] {
+			value = 1
+		}
+		fmt.Fprintf(w, "dnsbl_list_healthy{list=%v} %v\n", quoteLabel(address), value)
+	}
+
+	writeMetric(w, "dnsbl_hits", "gauge", "Number of DNSBLs the target is listed on.")
+	for _, report := range e.batch.Reports {
+		fmt.Fprintf(w, "dnsbl_hits{target=%v} %v\n", quoteLabel(report.Target), report.Hits)
+	}
+	writeMetric(w, "dnsbl_score", "gauge", "Reputation score of the target, the sum of the weights of all hits.")
+	for _, report := range e.batch.Reports {
+		fmt.Fprintf(w, "dnsbl_score{target=%v} %v\n", quoteLabel(report.Target), report.Score)
+	}
+	writeMetric(w, "dnsbl_check_duration_seconds", "gauge", "How long the latest check of the target took.")
+	for _, report := range e.batch.Reports {
+		fmt.Fprintf(w, "dnsbl_check_duration_seconds{target=%v} %v\n", quoteLabel(report.Target), report.Duration.Seconds())
+	}
+}
+
+// writeMetric writes the HELP and TYPE lines of metric `name`
+func writeMetric(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %v %v\n", name, help)
+	fmt.Fprintf(w, "# TYPE %v %v\n", name, typ)
+}
+
+// writeCounters writes counter `name` with a value per list, sorted by list
+func writeCounters(w io.Writer, name, help string, counters map[string]int) {
+	writeMetric(w, name, "counter", help)
+
+	lists := make([]string, 0, len(counters))
+	for address := range counters {
+		lists = append(lists, address)
+	}
+	sort.Strings(lists)
+
+	for _, address := range lists {
+		fmt.Fprintf(w, "%v{list=%v} %v\n", name, quoteLabel(address), counters[address])
+	}
+}
+
+// quoteLabel returns `value` as a quoted and escaped Prometheus label value
+func quoteLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+func Test_exporter_writeMetrics(t *testing.T) {
+	zen := &dnsbl.ListItem{Address: "zen.spamhaus.org"}
+	spamcop := &dnsbl.ListItem{Address: "bl.spamcop.net"}
+	batch := &dnsbl.BatchReport{
+		Time:     time.Unix(1577934245, 0),
+		Duration: 1500 * time.Millisecond,
+		Reports: []*dnsbl.Report{
+			{Target: "192.0.2.1", Results: []*dnsbl.Result{{List: zen, Status: dnsbl.StatusHit}, {List: spamcop, Status: dnsbl.StatusTimeout}},
+				Hits: 1, Score: 10, Duration: time.Second},
+			{Target: "192.0.2.2", Results: []*dnsbl.Result{{List: zen}, {List: spamcop, Status: dnsbl.StatusUnhealthy}}},
+		},
+	}
+
+	e := &exporter{}
+	e.update(batch)
+	e.update(batch)
+	out := &bytes.Buffer{}
+	e.writeMetrics(out)
+
+	for _, want := range []string{
+		"# TYPE dnsbl_checks_total counter\ndnsbl_checks_total 2\n",
+		"dnsbl_list_timeouts_total{list=\"bl.spamcop.net\"} 2\n",
+		"dnsbl_last_check_timestamp_seconds 1577934245\n",
+		"dnsbl_last_check_duration_seconds 1.5\n",
+		"dnsbl_listed{target=\"192.0.2.1\",list=\"zen.spamhaus.org\"} 1\n",
+		"dnsbl_listed{target=\"192.0.2.2\",list=\"zen.spamhaus.org\"} 0\n",
+		"dnsbl_list_healthy{list=\"bl.spamcop.net\"} 0\ndnsbl_list_healthy{list=\"zen.spamhaus.org\"} 1\n",
+		"dnsbl_hits{target=\"192.0.2.1\"} 1\n",
+		"dnsbl_score{target=\"192.0.2.1\"} 10\n",
+		"dnsbl_check_duration_seconds{target=\"192.0.2.1\"} 1\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("writeMetrics() missing %q in\n%v", want, out)
+		}
+	}
+	if strings.Contains(out.String(), `dnsbl_listed{target="192.0.2.1",list="bl.spamcop.net"}`) {
+		t.Errorf("writeMetrics() has dnsbl_listed for a timed out list")
+	}
+}
+
+// slowTargetResolver is a stubResolver that waits for `delay` before answering queries about `slow`
+type slowTargetResolver struct {
+	*stubResolver
+	slow  string
+	delay time.Duration
+}
+
+func (r *slowTargetResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if strings.HasPrefix(host, r.slow) {
+		time.Sleep(r.delay)
+	}
+	return r.stubResolver.LookupHost(ctx, host)
+}
+
+func Test_exporter_checkDuration(t *testing.T) {
+	checker := dnsbl.NewChecker([]*dnsbl.ListItem{{Address: "bl.example.com", IP4: true, Blacklist: true}})
+	checker.Threads = 2
+	checker.Resolver = &slowTargetResolver{
+		stubResolver: &stubResolver{hosts: map[string][]string{"2.0.0.127.bl.example.com": {"127.0.0.2"}}},
+		slow:         "2.2.0.192.",
+		delay:        200 * time.Millisecond,
+	}
+
+	e := &exporter{}
+	e.update(checker.CheckBatch(false, []string{"192.0.2.1", "192.0.2.2"}))
+	out := &bytes.Buffer{}
+	e.writeMetrics(out)
+
+	// each target reports how long its own check took, not the whole batch
+	durations := map[string]float64{}
+	for _, line := range strings.Split(out.String(), "\n") {
+		var target string
+		var seconds float64
+		if _, err := fmt.Sscanf(line, "dnsbl_check_duration_seconds{target=%q} %g", &target, &seconds); err == nil {
+			durations[target] = seconds
+		}
+	}
+	if d := durations["192.0.2.1"]; d >= 0.1 {
+		t.Errorf("dnsbl_check_duration_seconds of the fast target = %v, want less than 0.1", d)
+	}
+	if d := durations["192.0.2.2"]; d < 0.2 {
+		t.Errorf("dnsbl_check_duration_seconds of the slow target = %v, want at least 0.2", d)
+	}
+}
+
+func Test_quoteLabel(t *testing.T) {
+	if got := quoteLabel("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("quoteLabel() = %v", got)
+	}
+}
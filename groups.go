@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path"
+)
+
+// listGroups are named groups of DNSBLs that can be used with --only.
+// Each group is a set of addresses or glob patterns.
+var listGroups = map[string][]string{
+	"spamhaus":   {"*.spamhaus.org"},
+	"sorbs":      {"*.sorbs.net"},
+	"uribl":      {"*.uribl.com"},
+	"surbl":      {"*.surbl.org"},
+	"uceprotect": {"dnsbl-*.uceprotect.net"},
+	"barracuda":  {"*.barracudacentral.org"},
+	"mailspike":  {"*.mailspike.net"},
+	// major are the lists used by most large receivers
+	"major": {"zen.spamhaus.org", "dbl.spamhaus.org", "b.barracudacentral.org", "bl.spamcop.net", "dnsbl.sorbs.net",
+		"psbl.surriel.com", "bl.mailspike.net", "multi.uribl.com", "multi.surbl.org", "dnsbl-1.uceprotect.net"},
+}
+
+// matchesAny returns true if `address` matches any of `patterns`. A pattern is
+// an exact address, a glob pattern like *.spamhaus.org or the name of a list group.
+func matchesAny(address string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if group, ok := listGroups[pattern]; ok {
+			if matchesAny(address, group) {
+				return true
+			}
+			continue
+		}
+
+		if matched, _ := path.Match(pattern, address); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func Test_matchesAny(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		patterns []string
+		want     bool
+	}{
+		{"exact", "bl.spamcop.net", []string{"bl.spamcop.net"}, true},
+		{"exact mismatch", "bl.spamcop.net", []string{"spamcop.net"}, false},
+		{"glob", "zen.spamhaus.org", []string{"*.spamhaus.org"}, true},
+		{"glob multiple labels", "recent.spam.dnsbl.sorbs.net", []string{"*.sorbs.net"}, true},
+		{"glob mismatch", "zen.spamhaus.org", []string{"*.sorbs.net"}, false},
+		{"group", "dnsbl-1.uceprotect.net", []string{"uceprotect"}, true},
+		{"group mismatch", "bl.spamcop.net", []string{"spamhaus"}, false},
+		{"any of several", "multi.uribl.com", []string{"bl.spamcop.net", "uribl"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesAny(tt.address, tt.patterns); got != tt.want {
+				t.Errorf("matchesAny() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+type jsonList struct {
+	Name         string   `json:"name"`
+	Address      string   `json:"address"`
+	Capabilities []string `json:"capabilities"`
+	Type         string   `json:"type"`
+	Weight       float64  `json:"weight"`
+	Disabled     string   `json:"disabled,omitempty"`
+}
+
+// runLists prints the DNSBL catalogue filtered by the lists command flags
+func runLists(allLists []*dnsbl.ListItem) {
+	lists := filterCatalogue(allLists, *cfgListsCapability, *cfgListsType, *cfgListsSearch)
+
+	switch *cfgListsFormat {
+	case "json":
+		writeJSON(newJSONLists(lists))
+
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"name", "address", "capabilities", "type", "weight", "disabled"})
+		for _, item := range lists {
+			w.Write([]string{item.Name, item.Address, strings.Join(listCapabilities(item), " "), listType(item),
+				strconv.FormatFloat(item.EffectiveWeight(), 'f', -1, 64), item.Disabled})
+		}
+		w.Flush()
+
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "NAME\tADDRESS\tCAPABILITIES\tTYPE\tWEIGHT\tDISABLED\n")
+		for _, item := range lists {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", item.Name, item.Address, strings.Join(listCapabilities(item), ","), listType(item),
+				item.EffectiveWeight(), item.Disabled)
+		}
+		w.Flush()
+	}
+}
+
+// newJSONLists converts `lists` to their JSON representation
+func newJSONLists(lists []*dnsbl.ListItem) []*jsonList {
+	out := make([]*jsonList, 0, len(lists))
+	for _, item := range lists {
+		out = append(out, &jsonList{
+			Name:         item.Name,
+			Address:      item.Address,
+			Capabilities: listCapabilities(item),
+			Type:         listType(item),
+			Weight:       item.EffectiveWeight(),
+			Disabled:     item.Disabled,
+		})
+	}
+
+	return out
+}
+
+// filterCatalogue returns the lists in `lists` that support `capability`, are of type `listT`
+// and contain `search` in their name or address. Empty filters match every list.
+func filterCatalogue(lists []*dnsbl.ListItem, capability, listT, search string) []*dnsbl.ListItem {
+	search = strings.ToLower(search)
+	filtered := []*dnsbl.ListItem{}
+
+	for _, item := range lists {
+		if capability != "" && !isStringInSlice(capability, listCapabilities(item)) {
+			continue
+		}
+		if listT != "" && listT != listType(item) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(item.Name), search) && !strings.Contains(strings.ToLower(item.Address), search) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+
+	return filtered
+}
+
+// listCapabilities returns the kinds of targets `item` can check
+func listCapabilities(item *dnsbl.ListItem) []string {
+	capabilities := []string{}
+	if item.IP4 {
+		capabilities = append(capabilities, "ip4")
+	}
+	if item.IP6 {
+		capabilities = append(capabilities, "ip6")
+	}
+	if item.Domain {
+		capabilities = append(capabilities, "domain")
+	}
+
+	return capabilities
+}
+
+// listType returns the type of `item`: black, white, combined or info
+func listType(item *dnsbl.ListItem) string {
+	switch {
+	case item.Blacklist:
+		return "black"
+	case item.Whitelist:
+		return "white"
+	case item.Combined:
+		return "combined"
+	case item.Info:
+		return "info"
+	}
+
+	return ""
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+func Test_filterCatalogue(t *testing.T) {
+	lists := []*dnsbl.ListItem{
+		{Name: "Spamhaus ZEN", Address: "zen.spamhaus.org", IP4: true, IP6: true, Blacklist: true},
+		{Name: "Spamhaus DBL", Address: "dbl.spamhaus.org", Domain: true, Blacklist: true},
+		{Name: "DNSWL", Address: "list.dnswl.org", IP4: true, Whitelist: true},
+		{Name: "Abuse.net", Address: "contacts.abuse.net", Domain: true, Info: true},
+	}
+
+	tests := []struct {
+		name       string
+		capability string
+		listType   string
+		search     string
+		want       []string
+	}{
+		{"no filters", "", "", "", []string{"zen.spamhaus.org", "dbl.spamhaus.org", "list.dnswl.org", "contacts.abuse.net"}},
+		{"capability", "ip6", "", "", []string{"zen.spamhaus.org"}},
+		{"type", "", "info", "", []string{"contacts.abuse.net"}},
+		{"search name", "", "", "SPAMHAUS", []string{"zen.spamhaus.org", "dbl.spamhaus.org"}},
+		{"combined", "ip4", "black", "spamhaus", []string{"zen.spamhaus.org"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterCatalogue(lists, tt.capability, tt.listType, tt.search)
+			if len(got) != len(tt.want) {
+				t.Fatalf("filterCatalogue() returned %v lists, want %v", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].Address != tt.want[i] {
+					t.Errorf("filterCatalogue()[%d] = %v, want %v", i, got[i].Address, tt.want[i])
+				}
+			}
+		})
+	}
+}
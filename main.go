@@ -1,322 +1,338 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 	"strings"
-	"sync"
 
 	valid "github.com/asaskevich/govalidator"
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
 var (
-	app          = kingpin.New("dnsbl_checker", "All-in-one DNSBL checker written in Go using every publicly known DNSBL.")
-	ip4Cmd       = app.Command("ip", "checks IPv4 address against DNSBLs")
-	cfgWhitelist = app.Flag("whitelist", "Check whitelists instead of blacklists").Bool()
-	cfgVerbose   = app.Flag("verbose", "More verbose output. Output will include misses, timeouts and failures.").Bool()
-	cfgExclude   = app.Flag("exclude", "List of DNSBLs to exclude from the check. This flag can be specified multiple times.").PlaceHolder("bl.example.com").Strings()
-	cfgThreads   = app.Flag("threads", "number of concurrent checks between 1 (min) and 1000 (max)").Default("10").Int()
-	cfgIP4       = ip4Cmd.Arg("ip", "IP address to check").Required().String()
-	// ip6Cmd       = app.Command("ip6", "checks IPv6 address against DNSBLs")
-	// cfgIP6       = ip6Cmd.Arg("ip", "IP address to check").Required().String()
+	app                = kingpin.New("dnsbl_checker", "All-in-one DNSBL checker written in Go using every publicly known DNSBL.")
+	ip4Cmd             = app.Command("ip", "checks IPv4 address against DNSBLs")
+	cfgWhitelist       = app.Flag("whitelist", "Check whitelists instead of blacklists").Bool()
+	cfgVerbose         = app.Flag("verbose", "More verbose output. Output will include misses, timeouts and failures.").Bool()
+	cfgExclude         = app.Flag("exclude", "List of DNSBLs to exclude from the check. This flag can be specified multiple times.").PlaceHolder("bl.example.com").Strings()
+	cfgOnly            = app.Flag("only", "Only check these DNSBLs. Accepts addresses, glob patterns like *.spamhaus.org and groups (spamhaus, sorbs, uribl, surbl, uceprotect, barracuda, mailspike, major). This flag can be specified multiple times.").PlaceHolder("bl.example.com").Strings()
+	cfgThreads         = app.Flag("threads", "number of concurrent checks between 1 (min) and 1000 (max)").Default("10").Int()
+	cfgSpeed           = app.Flag("speed", "Largest number of DNS queries per second across all DNSBLs. 0 disables the limit.").Default("0").Float64()
+	cfgOperatorSpeed   = app.Flag("operator-speed", "Largest number of DNS queries per second to the DNSBLs of a single operator, e.g. all *.sorbs.net lists. 0 disables the limit.").Default("0").Float64()
+	cfgTimeout         = app.Flag("timeout", "How long a single DNS query may take").Default("3s").Duration()
+	cfgRetries         = app.Flag("retries", "How many times a DNS query that timed out is repeated").Default("1").Int()
+	cfgDeadline        = app.Flag("deadline", "Abort all outstanding queries after this long. Lists that did not answer are reported as timeouts. 0 disables the deadline.").Default("0").Duration()
+	cfgWarning         = app.Flag("warning", "Reputation score or number of hits at which the result is a warning (exit code 1)").Default("1").Float64()
+	cfgCritical        = app.Flag("critical", "Reputation score or number of hits at which the result is critical (exit code 2)").Default("10").Float64()
+	cfgThresholdOn     = app.Flag("threshold-on", "Apply --warning and --critical to the reputation score or the number of hits").Default("score").Enum("score", "hits")
+	cfgNagios          = app.Flag("nagios", "Nagios/Icinga plugin output: a single status line with performance data").Bool()
+	cfgMaxErrors       = app.Flag("max-errors", "In --nagios mode, the result is UNKNOWN (exit code 3) if more than this percentage of DNSBLs time out or fail").Default("50").Int()
+	cfgOutput          = app.Flag("output", "Output format: text or json").Default("text").Enum("text", "json")
+	cfgResolver        = app.Flag("resolver", "Recursive DNS server used for all queries instead of the system resolver. Use tls://host:853 for DNS-over-TLS or an https:// URL for DNS-over-HTTPS.").PlaceHolder("host:port").String()
+	cfgDNSClient       = app.Flag("dns-client", "DNS client: system uses the operating system's resolver, builtin sends queries directly to --resolver or the first nameserver in /etc/resolv.conf").Default("system").Enum("system", "builtin")
+	cfgIP4             = ip4Cmd.Arg("ip", "IP address to check").Required().String()
+	ip6Cmd             = app.Command("ip6", "checks IPv6 address against DNSBLs")
+	cfgIP6             = ip6Cmd.Arg("ip", "IP address to check").Required().String()
 	domainCmd          = app.Command("domain", "checks a domain against DNSBLs")
 	cfgDomain          = domainCmd.Arg("domain", "domain name to check").Required().String()
+	batchCmd           = app.Command("batch", "checks IP addresses, CIDR ranges and domains read from a file, one per line")
+	cfgBatchFile       = batchCmd.Arg("file", "file with targets to check. Standard input is read if omitted.").Default("-").String()
+	rangeCmd           = app.Command("range", "checks every IPv4 address in a CIDR range against DNSBLs")
+	cfgRange           = rangeCmd.Arg("cidr", "CIDR range to check, e.g. 192.0.2.0/24").Required().String()
+	monitorCmd         = app.Command("monitor", "periodically checks IP addresses, CIDR ranges and domains read from a file and prints listings that appear or disappear")
+	cfgMonitorFile     = monitorCmd.Arg("file", "file with targets to monitor, one per line").Required().String()
+	cfgInterval        = monitorCmd.Flag("interval", "Time between checks").Default("30m").Duration()
+	cfgJitter          = monitorCmd.Flag("jitter", "Largest random delay added to every interval").Default("2m").Duration()
+	cfgWebhooks        = monitorCmd.Flag("webhook", "URL that listings and delistings are posted to as JSON. This flag can be specified multiple times.").PlaceHolder("URL").Strings()
+	cfgSlackWebhooks   = monitorCmd.Flag("slack-webhook", "Slack-compatible incoming webhook URL that listings and delistings are posted to. This flag can be specified multiple times.").PlaceHolder("URL").Strings()
+	cfgSMTPServer      = monitorCmd.Flag("smtp", "SMTP server that listings and delistings are emailed through").PlaceHolder("host:port").String()
+	cfgSMTPUser        = monitorCmd.Flag("smtp-user", "SMTP username").String()
+	cfgSMTPPassword    = monitorCmd.Flag("smtp-password", "SMTP password").Envar("DNSBL_SMTP_PASSWORD").String()
+	cfgMailFrom        = monitorCmd.Flag("mail-from", "Sender address of notification emails").String()
+	cfgMailTo          = monitorCmd.Flag("mail-to", "Recipient of notification emails. This flag can be specified multiple times.").Strings()
+	exporterCmd        = app.Command("exporter", "periodically checks IP addresses, CIDR ranges and domains read from a file and serves the results as Prometheus metrics")
+	cfgExporterFile    = exporterCmd.Arg("file", "file with targets to check, one per line").Required().String()
+	cfgListen          = exporterCmd.Flag("listen", "Address the /metrics endpoint listens on").Default(":9810").String()
+	cfgExportInterval  = exporterCmd.Flag("interval", "Time between checks").Default("30m").Duration()
+	cfgExportJitter    = exporterCmd.Flag("jitter", "Largest random delay added to every interval").Default("2m").Duration()
+	serveCmd           = app.Command("serve", "serves a REST API for checking IP addresses and domains")
+	cfgServeListen     = serveCmd.Flag("listen", "Address the API listens on").Default(":8080").String()
+	cfgAPIToken        = serveCmd.Flag("token", "Bearer token required by every request. Authentication is disabled if empty.").Envar("DNSBL_API_TOKEN").String()
+	cfgRequestTimeout  = serveCmd.Flag("request-timeout", "How long a request may wait for its check").Default("30s").Duration()
+	cfgMaxConcurrent   = serveCmd.Flag("max-concurrent", "Largest number of checks running at the same time across all requests").Default("4").Int()
+	infoCmd            = app.Command("info", "queries informational DNSBLs for abuse contacts and other details of an IP address or domain")
+	cfgInfo            = infoCmd.Arg("target", "IP address or domain name to look up").Required().String()
+	listsCmd           = app.Command("lists", "lists the built-in DNSBL catalogue")
+	cfgListsCapability = listsCmd.Flag("capability", "Only show lists that can check ip4, ip6 or domain").Enum("ip4", "ip6", "domain")
+	cfgListsType       = listsCmd.Flag("type", "Only show lists of type black, white, combined or info").Enum("black", "white", "combined", "info")
+	cfgListsSearch     = listsCmd.Flag("search", "Only show lists whose name or address contains this text").String()
+	cfgListsAll        = listsCmd.Flag("all", "Also show disabled lists").Bool()
+	cfgListsFormat     = listsCmd.Flag("format", "Output format: table, json or csv").Default("table").Enum("table", "json", "csv")
+	cfgListsFile       = app.Flag("lists-file", "YAML, JSON or CSV file with DNSBL definitions that are merged with the built-in catalogue").PlaceHolder("lists.yaml").String()
+	cfgReplaceLists    = app.Flag("replace-lists", "Replace the built-in catalogue with the lists from --lists-file instead of merging them").Bool()
+	cfgHealthTTL       = app.Flag("health-ttl", "How long healthy DNSBLs are remembered between runs. 0 disables the cache.").Default("1h").Duration()
+	cfgRefresh         = app.Flag("refresh-health", "Recheck the health of all DNSBLs, ignoring cached results").Bool()
+	cfgMaxRange        = app.Flag("max-range", "Largest number of addresses a CIDR range may contain").Default("256").Int()
 	version            = "0.2"
-	ErrWrongResponse   = fmt.Errorf("RBL returned a response outside of 127.0.0.0/8 subnet")
-	ErrRBLPositiveFail = fmt.Errorf("RBL failed positive check")
-	ErrRBLNegativeFail = fmt.Errorf("RBL failed negative check")
-	ErrRBLFail         = fmt.Errorf("RBL failed both checks")
 )
 
-// ListItem is a struct with list details
-type ListItem struct {
-	// Name is the name of the list
-	Name string
-	// Address is the hostname used for checking
-	Address string
-	// IP4 is true if this list is used for checking IP4 addresses
-	IP4 bool
-	// IP6 is true if this list is used for checking IP4 addresses
-	IP6 bool
-	// Domain is true if this list is used for checking domains
-	Domain bool
-	// Blacklist is true if this list is a blacklist
-	Blacklist bool
-	// Whitelist is true if this list is a whitelist
-	Whitelist bool
-}
-
-type workUnit struct {
-	// address is the hostname used for checking
-	address string
-	// listItem is the ListItem
-	listItem *ListItem
-
-	counterHits     chan bool
-	counterMisses   chan bool
-	counterTimeouts chan bool
-	counterFailures chan bool
-	lookupFunc      func(string, *ListItem) (bool, error)
-}
-
 func main() {
 	app.Version(version)
 
 	ks := kingpin.MustParse(app.Parse(os.Args[1:]))
-	allLists := parseCVS()
-	filteredLists := []*ListItem{}
 
-	// create filteredLists by removing excluded lists from allLists
-	if len(*cfgExclude) >= 1 {
+	if *cfgNagios && ks != ip4Cmd.FullCommand() && ks != ip6Cmd.FullCommand() && ks != domainCmd.FullCommand() {
+		app.FatalUsage("--nagios is only supported by the ip, ip6 and domain commands.")
+	}
+
+	if ks == listsCmd.FullCommand() {
+		runLists(loadCatalogue(*cfgListsAll))
+		return
+	}
+
+	allLists := loadCatalogue(false)
+	filteredLists := []*dnsbl.ListItem{}
+
+	// create filteredLists by keeping only included lists and removing excluded lists from allLists
+	for _, vAll := range allLists {
+		if len(*cfgOnly) >= 1 && !matchesAny(vAll.Address, *cfgOnly) {
+			continue
+		}
+		if isStringInSlice(vAll.Address, *cfgExclude) {
+			continue
+		}
+		filteredLists = append(filteredLists, vAll)
+	}
+
+	// every --only value must select at least one list, so typos don't go unnoticed
+	for _, pattern := range *cfgOnly {
+		found := false
 		for _, vAll := range allLists {
-			if isStringInSlice(vAll.Address, *cfgExclude) {
-				continue
+			if matchesAny(vAll.Address, []string{pattern}) {
+				found = true
+				break
 			}
-			filteredLists = append(filteredLists, vAll)
 		}
-	} else {
-		filteredLists = allLists
+		if !found {
+			app.FatalUsage("--only %v does not match any DNSBL.", pattern)
+		}
 	}
 
+	checker := dnsbl.NewChecker(filteredLists)
+	checker.Threads = *cfgThreads
+	checker.Timeout = *cfgTimeout
+	checker.Retries = *cfgRetries
+	checker.RateLimit = *cfgSpeed
+	checker.OperatorRateLimit = *cfgOperatorSpeed
+	if *cfgDNSClient == "builtin" {
+		checker.Resolver = dnsbl.NewClient(builtinServer())
+	} else if *cfgResolver != "" {
+		checker.Resolver = dnsbl.NewResolver(*cfgResolver)
+	}
+	checker.HealthCache = openHealthCache()
+
+	ctx, cancel := checkContext()
+	defer cancel()
+
+	var report *dnsbl.Report
+
 	switch ks {
 	case ip4Cmd.FullCommand():
 		if !valid.IsIPv4(*cfgIP4) {
 			app.FatalUsage("You have not supplied a valid IP4 address.")
 		}
-		CheckIP4(*cfgWhitelist, *cfgIP4, filteredLists)
+		report = checker.CheckIP4Context(ctx, *cfgWhitelist, *cfgIP4)
 
-	// case ip6Cmd.FullCommand():
-	// 	if !valid.IsIPv6(*cfgIP6) {
-	// 		app.FatalUsage("You have not supplied a valid IP6 address.")
-	// 	}
-	// CheckIP6(*cfgWhitelist, *cfgIP6, filteredLists)
+	case ip6Cmd.FullCommand():
+		if !valid.IsIPv6(*cfgIP6) {
+			app.FatalUsage("You have not supplied a valid IP6 address.")
+		}
+		report = checker.CheckIP6Context(ctx, *cfgWhitelist, *cfgIP6)
 
 	case domainCmd.FullCommand():
 		if !valid.IsDNSName(*cfgDomain) {
 			app.FatalUsage("You have not supplied a valid domain name.")
 		}
-		CheckDomain(*cfgWhitelist, *cfgDomain, filteredLists)
-	}
+		report = checker.CheckDomainContext(ctx, *cfgWhitelist, *cfgDomain)
 
-}
+	case batchCmd.FullCommand():
+		runBatch(ctx, checker)
+		return
 
-// CheckIP4 .
-func CheckIP4(whitelist bool, ip string, allLists []*ListItem) {
-	lists := []*ListItem{}
-	for _, v := range allLists {
-		if v.IP4 && v.Whitelist == whitelist {
-			lists = append(lists, v)
-		}
-	}
+	case rangeCmd.FullCommand():
+		runRange(ctx, checker)
+		return
 
-	runChecks(ip, lists, lookupIP4)
-}
+	case monitorCmd.FullCommand():
+		runMonitor(checker)
+		return
+
+	case exporterCmd.FullCommand():
+		runExporter(checker)
+		return
 
-// CheckDomain .
-func CheckDomain(whitelist bool, domain string, allLists []*ListItem) {
-	lists := []*ListItem{}
-	for _, v := range allLists {
-		if v.Domain && v.Whitelist == whitelist {
-			lists = append(lists, v)
+	case serveCmd.FullCommand():
+		runServe(checker)
+		return
+
+	case infoCmd.FullCommand():
+		if net.ParseIP(*cfgInfo) == nil && !valid.IsDNSName(*cfgInfo) {
+			app.FatalUsage("You have not supplied a valid IP address or domain name.")
+		}
+		report = checker.InfoContext(ctx, *cfgInfo)
+		if *cfgOutput == "json" {
+			printJSON(report)
+		} else {
+			printInfo(report)
 		}
+		return
 	}
 
-	runChecks(domain, lists, lookupDomain)
-}
+	saveHealthCache(checker)
 
-// lookupIP4 returns true if `ip` is listed, false otherwise
-func lookupIP4(ip string, list *ListItem) (bool, error) {
-	// check RBL health before using it
-	if err := checkIP4Health(list.Address); err != nil {
-		return false, err
+	if *cfgNagios {
+		severity := nagiosSeverity(report, *cfgMaxErrors)
+		fmt.Println(nagiosOutput(report, severity))
+		os.Exit(int(severity))
 	}
 
-	stringyIP := strings.Split(ip, ".")
-	addr := stringyIP[3] + "." + stringyIP[2] + "." + stringyIP[1] + "." + stringyIP[0] + "." + list.Address
+	if *cfgOutput == "json" {
+		printJSON(report)
+	} else {
+		printReport(report)
+	}
 
-	ips, err := net.LookupIP(addr)
-	if err != nil {
-		return false, err
+	if !*cfgWhitelist {
+		os.Exit(int(reportSeverity(report)))
 	}
+}
 
-	if len(ips) > 0 {
-		_, subNet, _ := net.ParseCIDR("127.0.0.0/8")
-		if !subNet.Contains(ips[0]) {
-			return false, ErrWrongResponse
-		}
-		return true, nil
+// checkContext returns the context of a single check, which is done after --deadline
+func checkContext() (context.Context, context.CancelFunc) {
+	if *cfgDeadline <= 0 {
+		return context.WithCancel(context.Background())
 	}
 
-	return false, nil
+	return context.WithTimeout(context.Background(), *cfgDeadline)
 }
 
-// lookupDomain returns true if `domain` is listed, false otherwise
-func lookupDomain(domain string, list *ListItem) (bool, error) {
-	// check RBL health before using it
-	if !checkDomainHealth(list.Address) {
-		fmt.Printf("%v : ERROR\n", list.Address)
-		return false, fmt.Errorf("RBL is not working properly")
+// builtinServer returns the DNS server of the built-in client: --resolver if set,
+// otherwise the first nameserver in /etc/resolv.conf
+func builtinServer() string {
+	if *cfgResolver != "" {
+		return *cfgResolver
 	}
 
-	addrs, err := net.LookupHost(domain + "." + list.Address)
+	f, err := os.Open("/etc/resolv.conf")
 	if err != nil {
-		return false, err
+		app.Fatalf("--dns-client builtin needs --resolver: %v", err)
 	}
+	defer f.Close()
 
-	if len(addrs) > 0 {
-		return true, nil
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return fields[1]
+		}
 	}
 
-	return false, nil
+	app.Fatalf("--dns-client builtin needs --resolver: no nameserver in /etc/resolv.conf")
+	return ""
 }
 
-func worker(wg *sync.WaitGroup, ch chan *workUnit, done chan bool) {
-	wg.Add(1)
-	for {
-		select {
-		case <-done:
-			wg.Done()
-			return
-		case wu := <-ch:
-			result, err := wu.lookupFunc(wu.address, wu.listItem)
-			if err != nil {
-				var out string
-				if strings.HasSuffix(err.Error(), "no such host") {
-					out = fmt.Sprintf("%v : MISS\n", wu.listItem.Address)
-					wu.counterMisses <- true
-				} else if strings.HasSuffix(err.Error(), "i/o timeout") {
-					out = fmt.Sprintf("%v : TIMEOUT\n", wu.listItem.Address)
-					wu.counterTimeouts <- true
-				} else {
-					out = fmt.Sprintf("%v : FAILURE: %v\n", wu.listItem.Address, err)
-					wu.counterFailures <- true
-				}
-				if *cfgVerbose {
-					fmt.Printf("%v", out)
-				}
-			}
-			if result {
-				fmt.Printf("%v : HIT\n", wu.listItem.Address)
-				wu.counterHits <- true
-			}
+// loadCatalogue returns the built-in catalogue, merged with or replaced by the lists
+// from --lists-file. Disabled lists are only included if `all` is true.
+func loadCatalogue(all bool) []*dnsbl.ListItem {
+	lists := dnsbl.AllLists()
 
+	if *cfgListsFile != "" {
+		fileLists, err := dnsbl.LoadLists(*cfgListsFile)
+		if err != nil {
+			app.Fatalf("%v", err)
 		}
-	}
 
-}
+		if *cfgReplaceLists {
+			lists = fileLists
+		} else {
+			lists = dnsbl.MergeLists(lists, fileLists)
+		}
+	} else if *cfgReplaceLists {
+		app.FatalUsage("--replace-lists requires --lists-file")
+	}
 
-func runChecks(address string, lists []*ListItem, lookupFunc func(string, *ListItem) (bool, error)) {
-	wg := &sync.WaitGroup{}
-	counterHits := make(chan bool, len(lists))
-	counterMisses := make(chan bool, len(lists))
-	counterTimeouts := make(chan bool, len(lists))
-	counterFailures := make(chan bool, len(lists))
-	counterChecks := 0
-	workChan := make(chan *workUnit)
-	workDone := make(chan bool)
-
-	for i := 1; i <= *cfgThreads; i++ {
-		go worker(wg, workChan, workDone)
+	if all {
+		return lists
 	}
 
-	for _, listItem := range lists {
-		counterChecks++
-
-		wu := &workUnit{
-			address:         address,
-			listItem:        listItem,
-			counterFailures: counterFailures,
-			counterHits:     counterHits,
-			counterMisses:   counterMisses,
-			counterTimeouts: counterTimeouts,
-			lookupFunc:      lookupFunc,
+	enabled := []*dnsbl.ListItem{}
+	for _, item := range lists {
+		if item.Disabled == "" {
+			enabled = append(enabled, item)
 		}
-
-		workChan <- wu
 	}
 
-	close(workDone)
-	wg.Wait()
-
-	numListed := len(counterHits)
-
-	fmt.Printf("------------------------------------------------\n")
-	fmt.Printf("Result: %v checks performed. %v hits, %v misses, %v timeouts, %v failures\n", counterChecks, len(counterHits), len(counterMisses), len(counterTimeouts), len(counterFailures))
+	return enabled
+}
 
-	if numListed > 0 && !*cfgWhitelist {
-		os.Exit(2)
+// openHealthCache returns the list health cache from the user's cache directory,
+// or nil if caching is disabled or not possible
+func openHealthCache() *dnsbl.HealthCache {
+	if *cfgHealthTTL <= 0 {
+		return nil
 	}
 
-	os.Exit(0)
-}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	path := filepath.Join(dir, "dnsbl_checker", "health.json")
 
-// isStringInSlice returns true if `needle` is in `haystrack`
-func isStringInSlice(needle string, haystrack []string) bool {
-	for _, v := range haystrack {
-		if needle == v {
-			return true
-		}
+	hc, err := dnsbl.LoadHealthCache(path, *cfgHealthTTL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring health cache %v: %v\n", path, err)
+		return &dnsbl.HealthCache{Path: path, TTL: *cfgHealthTTL, Resolver: resolverName(), Refresh: *cfgRefresh}
 	}
+	hc.Resolver = resolverName()
+	hc.Refresh = *cfgRefresh
 
-	return false
+	return hc
 }
 
-// checkIP4Health returns true if `list` is healthy. Returns false otherwise.
-// Test specification: https://tools.ietf.org/html/rfc5782#page-7
-func checkIP4Health(list string) error {
-	testNegative := func(list string) bool {
-		ips, err := net.LookupHost("1.0.0.127" + "." + list)
-		if len(ips) == 0 && strings.HasSuffix(err.Error(), "no such host") {
-			return true
-		}
-		return false
+// resolverName identifies the DNS client and server that queries are sent to
+func resolverName() string {
+	if *cfgDNSClient == "builtin" {
+		return "builtin " + builtinServer()
 	}
-
-	testPositive := func(list string) bool {
-		ips, err := net.LookupHost("2.0.0.127" + "." + list)
-		if len(ips) > 0 && err == nil {
-			return true
-		}
-		return false
+	if *cfgResolver != "" {
+		return "system " + *cfgResolver
 	}
 
-	negResult := testNegative(list)
-	posResult := testPositive(list)
+	return "system"
+}
 
-	if !negResult && !posResult {
-		return ErrRBLFail
-	} else if negResult && !posResult {
-		return ErrRBLPositiveFail
-	} else if !negResult && posResult {
-		return ErrRBLNegativeFail
+// saveHealthCache saves the checker's list health cache, if there is one
+func saveHealthCache(checker *dnsbl.Checker) {
+	if checker.HealthCache == nil {
+		return
 	}
 
-	return nil
-}
-
-// checkDomainHealth returns true if `list` is healthy. Returns false otherwise.
-// Test specification: https://tools.ietf.org/html/rfc5782#page-7
-func checkDomainHealth(list string) bool {
-	testNegative := func(list string) bool {
-		ips, err := net.LookupHost("INVALID" + "." + list)
-		if len(ips) == 0 && strings.HasSuffix(err.Error(), "no such host") {
-			return true
-		}
-		return false
+	if err := checker.HealthCache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not save health cache: %v\n", err)
 	}
+}
 
-	testPositive := func(list string) bool {
-		ips, err := net.LookupHost("TEST" + "." + list)
-		if len(ips) > 0 && err == nil {
+// isStringInSlice returns true if `needle` is in `haystrack`
+func isStringInSlice(needle string, haystrack []string) bool {
+	for _, v := range haystrack {
+		if needle == v {
 			return true
 		}
-		return false
-	}
-
-	if testNegative(list) && testPositive(list) {
-		return true
 	}
 
 	return false
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+type jsonEvent struct {
+	Event       string             `json:"event"`
+	Target      string             `json:"target"`
+	List        string             `json:"list"`
+	Name        string             `json:"name"`
+	Timestamp   time.Time          `json:"timestamp"`
+	ReturnCodes []dnsbl.ReturnCode `json:"return_codes,omitempty"`
+	TXT         []string           `json:"txt,omitempty"`
+	Weight      float64            `json:"weight"`
+}
+
+// runMonitor checks all targets from the monitor file every --interval and prints
+// listings that appear or disappear, until it is interrupted
+func runMonitor(checker *dnsbl.Checker) {
+	f, err := os.Open(*cfgMonitorFile)
+	if err != nil {
+		app.Fatalf("%v", err)
+	}
+	targets, err := readTargets(f, *cfgMaxRange)
+	f.Close()
+	if err != nil {
+		app.Fatalf("%v", err)
+	}
+	if len(targets) == 0 {
+		app.Fatalf("%v does not contain any targets", *cfgMonitorFile)
+	}
+
+	notifiers := newNotifiers()
+
+	m := dnsbl.NewMonitor(checker, targets)
+	m.Whitelist = *cfgWhitelist
+	m.Interval = *cfgInterval
+	m.Jitter = *cfgJitter
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	enc := json.NewEncoder(os.Stdout)
+	m.Run(stop, func(batch *dnsbl.BatchReport, events []dnsbl.Event) {
+		saveHealthCache(checker)
+
+		for _, e := range events {
+			if *cfgOutput == "json" {
+				if err := enc.Encode(newJSONEvent(e)); err != nil {
+					log.Fatal(err)
+				}
+			} else {
+				fmt.Println(formatEvent(e))
+			}
+		}
+
+		if len(events) > 0 {
+			for _, n := range notifiers {
+				if err := n.notify(events); err != nil {
+					fmt.Fprintf(os.Stderr, "Notification failed: %v\n", err)
+				}
+			}
+		}
+
+		if *cfgVerbose {
+			fmt.Fprintf(os.Stderr, "%v: %v targets checked in %v, %v listed. %v hits, %v timeouts, %v failures, %v servfail, %v refused, %v unhealthy, %v blocked\n",
+				batch.Time.UTC().Format(time.RFC3339), batch.Targets, batch.Duration.Round(time.Millisecond), batch.Listed,
+				batch.Hits, batch.Timeouts, batch.Failures, batch.ServFails, batch.Refused, batch.Unhealthy, batch.Blocked)
+		}
+	})
+}
+
+// formatEvent returns `e` as a single line of text
+func formatEvent(e dnsbl.Event) string {
+	line := fmt.Sprintf("%v %v %v %v", e.Time.UTC().Format(time.RFC3339), e.Type, e.Target, e.List.Address)
+	if len(e.ReturnCodes) > 0 {
+		line += " (" + formatReturnCodes(e.ReturnCodes) + ")"
+	}
+	if len(e.TXT) > 0 {
+		line += " " + strings.Join(e.TXT, " | ")
+	}
+
+	return line
+}
+
+// newJSONEvent converts `e` to its JSON representation
+func newJSONEvent(e dnsbl.Event) *jsonEvent {
+	return &jsonEvent{
+		Event:       strings.ToLower(e.Type.String()),
+		Target:      e.Target,
+		List:        e.List.Address,
+		Name:        e.List.Name,
+		Timestamp:   e.Time.UTC(),
+		ReturnCodes: e.ReturnCodes,
+		TXT:         e.TXT,
+		Weight:      e.List.EffectiveWeight(),
+	}
+}
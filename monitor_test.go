@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+func Test_formatEvent(t *testing.T) {
+	list := &dnsbl.ListItem{Address: "bl.example.com"}
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		event dnsbl.Event
+		want  string
+	}{
+		{
+			"listed",
+			dnsbl.Event{Type: dnsbl.EventListed, Target: "192.0.2.1", List: list, Time: now,
+				ReturnCodes: []dnsbl.ReturnCode{{Code: "127.0.0.2", Meaning: "spam source"}}, TXT: []string{"https://bl.example.com/192.0.2.1"}},
+			"2020-01-02T03:04:05Z LISTED 192.0.2.1 bl.example.com (127.0.0.2 spam source) https://bl.example.com/192.0.2.1",
+		},
+		{
+			"delisted",
+			dnsbl.Event{Type: dnsbl.EventDelisted, Target: "192.0.2.1", List: list, Time: now},
+			"2020-01-02T03:04:05Z DELISTED 192.0.2.1 bl.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatEvent(tt.event); got != tt.want {
+				t.Errorf("formatEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+// reportSeverity returns the severity of `report` for the --warning and --critical
+// thresholds on the score or number of hits. Whitelist hits are always OK.
+func reportSeverity(report *dnsbl.Report) dnsbl.Severity {
+	if report.Whitelist {
+		return dnsbl.SeverityOK
+	}
+	if *cfgThresholdOn == "hits" {
+		return dnsbl.ThresholdSeverity(float64(report.Hits), *cfgWarning, *cfgCritical)
+	}
+
+	return report.Severity(*cfgWarning, *cfgCritical)
+}
+
+// batchSeverity returns the highest severity of all targets in `batch`. Whitelist hits are always OK.
+func batchSeverity(batch *dnsbl.BatchReport) dnsbl.Severity {
+	if batch.Whitelist {
+		return dnsbl.SeverityOK
+	}
+	if *cfgThresholdOn == "hits" {
+		return dnsbl.ThresholdSeverity(float64(batch.MaxHits), *cfgWarning, *cfgCritical)
+	}
+
+	return batch.Severity(*cfgWarning, *cfgCritical)
+}
+
+// nagiosSeverity returns the severity of `report` for a Nagios plugin. It is UNKNOWN
+// if more than `maxErrors` percent of the lists could not be checked.
+func nagiosSeverity(report *dnsbl.Report, maxErrors int) dnsbl.Severity {
+	errors := report.Timeouts + report.Failures + report.ServFails + report.Refused + report.Unhealthy + report.Blocked
+	if report.Checks == 0 || errors*100 > report.Checks*maxErrors {
+		return dnsbl.SeverityUnknown
+	}
+
+	return reportSeverity(report)
+}
+
+// nagiosOutput returns the single line status of `report` with performance data,
+// as specified by the Nagios plugin guidelines
+func nagiosOutput(report *dnsbl.Report, severity dnsbl.Severity) string {
+	var status string
+	listed := []string{}
+	for _, res := range report.Results {
+		if res.Status == dnsbl.StatusHit {
+			listed = append(listed, res.List.Address)
+		}
+	}
+
+	switch {
+	case severity == dnsbl.SeverityUnknown && report.Checks == 0:
+		status = "no DNSBLs to check"
+	case severity == dnsbl.SeverityUnknown:
+		status = fmt.Sprintf("%v of %v DNSBLs could not be checked", report.Checks-report.Hits-report.Misses, report.Checks)
+	case len(listed) > 0:
+		status = fmt.Sprintf("%v is listed on %v of %v DNSBLs (score %v): %v", report.Target, len(listed), report.Checks, report.Score, strings.Join(listed, ", "))
+	default:
+		status = fmt.Sprintf("%v is not listed on any of %v DNSBLs", report.Target, report.Checks)
+	}
+
+	// perfdata is value;warn;crit;min;max, so hits keep empty thresholds before their min and max
+	hitsThresholds, scoreThresholds := ";;", ""
+	thresholds := fmt.Sprintf(";%v;%v", *cfgWarning, *cfgCritical)
+	if *cfgThresholdOn == "hits" {
+		hitsThresholds = thresholds
+	} else {
+		scoreThresholds = thresholds
+	}
+
+	perfdata := fmt.Sprintf("hits=%v%v;0;%v score=%v%v misses=%v timeouts=%v failures=%v servfails=%v refused=%v unhealthy=%v blocked=%v time=%.3fs",
+		report.Hits, hitsThresholds, report.Checks, report.Score, scoreThresholds, report.Misses, report.Timeouts,
+		report.Failures, report.ServFails, report.Refused, report.Unhealthy, report.Blocked, report.Duration.Seconds())
+
+	return fmt.Sprintf("DNSBL %v - %v | %v", severity, status, perfdata)
+}
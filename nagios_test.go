@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+func Test_nagiosOutput(t *testing.T) {
+	*cfgWarning, *cfgCritical, *cfgThresholdOn = 1, 10, "score"
+
+	zen := &dnsbl.ListItem{Address: "zen.spamhaus.org", Weight: 10}
+	other := &dnsbl.ListItem{Address: "bl.example.com"}
+	tests := []struct {
+		name    string
+		report  *dnsbl.Report
+		wantSev dnsbl.Severity
+		want    string
+	}{
+		{
+			"listed",
+			&dnsbl.Report{Target: "192.0.2.1", Results: []*dnsbl.Result{{List: zen, Status: dnsbl.StatusHit}, {List: other}},
+				Checks: 2, Hits: 1, Misses: 1, Score: 10, Duration: 1500 * time.Millisecond},
+			dnsbl.SeverityCritical,
+			"DNSBL CRITICAL - 192.0.2.1 is listed on 1 of 2 DNSBLs (score 10): zen.spamhaus.org | hits=1;;;0;2 score=10;1;10 misses=1 timeouts=0 failures=0 servfails=0 refused=0 unhealthy=0 blocked=0 time=1.500s",
+		},
+		{
+			"clean",
+			&dnsbl.Report{Target: "192.0.2.1", Results: []*dnsbl.Result{{List: zen}, {List: other}}, Checks: 2, Misses: 2},
+			dnsbl.SeverityOK,
+			"DNSBL OK - 192.0.2.1 is not listed on any of 2 DNSBLs | hits=0;;;0;2 score=0;1;10 misses=2 timeouts=0 failures=0 servfails=0 refused=0 unhealthy=0 blocked=0 time=0.000s",
+		},
+		{
+			"too many errors",
+			&dnsbl.Report{Target: "192.0.2.1", Results: []*dnsbl.Result{{List: zen, Status: dnsbl.StatusTimeout}, {List: other, Status: dnsbl.StatusUnhealthy}},
+				Checks: 2, Timeouts: 1, Unhealthy: 1},
+			dnsbl.SeverityUnknown,
+			"DNSBL UNKNOWN - 2 of 2 DNSBLs could not be checked | hits=0;;;0;2 score=0;1;10 misses=0 timeouts=1 failures=0 servfails=0 refused=0 unhealthy=1 blocked=0 time=0.000s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sev := nagiosSeverity(tt.report, 50)
+			if sev != tt.wantSev {
+				t.Errorf("nagiosSeverity() = %v, want %v", sev, tt.wantSev)
+			}
+			if got := nagiosOutput(tt.report, sev); got != tt.want {
+				t.Errorf("nagiosOutput() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+
+	*cfgThresholdOn = "hits"
+	defer func() { *cfgThresholdOn = "score" }()
+	report := &dnsbl.Report{Target: "192.0.2.1", Results: []*dnsbl.Result{{List: zen, Status: dnsbl.StatusHit}, {List: other}}, Checks: 2, Hits: 1, Misses: 1, Score: 10}
+	want := "DNSBL OK - 192.0.2.1 is listed on 1 of 2 DNSBLs (score 10): zen.spamhaus.org | hits=1;1;10;0;2 score=10 misses=1 timeouts=0 failures=0 servfails=0 refused=0 unhealthy=0 blocked=0 time=0.000s"
+	if got := nagiosOutput(report, dnsbl.SeverityOK); got != want {
+		t.Errorf("nagiosOutput() with --threshold-on hits =\n%v\nwant\n%v", got, want)
+	}
+
+	// being on a whitelist is not a problem
+	report.Whitelist = true
+	if sev := nagiosSeverity(report, 50); sev != dnsbl.SeverityOK {
+		t.Errorf("nagiosSeverity() with --whitelist = %v, want %v", sev, dnsbl.SeverityOK)
+	}
+	if sev := newJSONReport(report).Severity; sev != dnsbl.SeverityOK.String() {
+		t.Errorf("newJSONReport() severity with --whitelist = %v, want %v", sev, dnsbl.SeverityOK)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+// notifyTimeout is how long a notifier may take to deliver a notification
+const notifyTimeout = 10 * time.Second
+
+// notifier sends listing and delisting events to an external service
+type notifier interface {
+	notify(events []dnsbl.Event) error
+}
+
+// webhookNotifier posts the events as JSON to an HTTP endpoint
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+type jsonNotification struct {
+	Events []*jsonEvent `json:"events"`
+}
+
+func (n *webhookNotifier) notify(events []dnsbl.Event) error {
+	payload := &jsonNotification{Events: make([]*jsonEvent, 0, len(events))}
+	for _, e := range events {
+		payload.Events = append(payload.Events, newJSONEvent(e))
+	}
+
+	return postJSON(n.client, n.url, payload)
+}
+
+// slackNotifier posts the events as a message to a Slack-compatible incoming webhook
+type slackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (n *slackNotifier) notify(events []dnsbl.Event) error {
+	lines := make([]string, 0, len(events))
+	for _, e := range events {
+		lines = append(lines, describeEvent(e))
+	}
+
+	return postJSON(n.client, n.url, map[string]string{"text": strings.Join(lines, "\n")})
+}
+
+// emailNotifier sends the events in an email through an SMTP server
+type emailNotifier struct {
+	// server is the host:port of the SMTP server
+	server string
+	from   string
+	to     []string
+	// auth is nil if the server does not require authentication
+	auth smtp.Auth
+}
+
+func (n *emailNotifier) notify(events []dnsbl.Event) error {
+	return smtp.SendMail(n.server, n.auth, n.from, n.to, emailMessage(n.from, n.to, events, time.Now()))
+}
+
+// newNotifiers returns the notifiers configured with command line flags
+func newNotifiers() []notifier {
+	client := &http.Client{Timeout: notifyTimeout}
+	notifiers := []notifier{}
+
+	for _, url := range *cfgWebhooks {
+		notifiers = append(notifiers, &webhookNotifier{url: url, client: client})
+	}
+	for _, url := range *cfgSlackWebhooks {
+		notifiers = append(notifiers, &slackNotifier{url: url, client: client})
+	}
+
+	if *cfgSMTPServer != "" {
+		if *cfgMailFrom == "" || len(*cfgMailTo) == 0 {
+			app.FatalUsage("--smtp requires --mail-from and --mail-to")
+		}
+		host, _, err := net.SplitHostPort(*cfgSMTPServer)
+		if err != nil {
+			app.FatalUsage("--smtp %v: %v", *cfgSMTPServer, err)
+		}
+
+		n := &emailNotifier{server: *cfgSMTPServer, from: *cfgMailFrom, to: *cfgMailTo}
+		if *cfgSMTPUser != "" {
+			n.auth = smtp.PlainAuth("", *cfgSMTPUser, *cfgSMTPPassword, host)
+		}
+		notifiers = append(notifiers, n)
+	} else if *cfgMailFrom != "" || len(*cfgMailTo) > 0 {
+		app.FatalUsage("--mail-from and --mail-to require --smtp")
+	}
+
+	return notifiers
+}
+
+// postJSON posts `v` as JSON to `url` and returns an error unless the response is successful
+func postJSON(client *http.Client, url string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%v returned %v", url, resp.Status)
+	}
+
+	return nil
+}
+
+// describeEvent returns a human readable description of `e` with the list name,
+// return codes and TXT reason
+func describeEvent(e dnsbl.Event) string {
+	list := e.List.Address
+	if e.List.Name != "" {
+		list = e.List.Name + " (" + e.List.Address + ")"
+	}
+
+	if e.Type == dnsbl.EventDelisted {
+		return fmt.Sprintf("%v was removed from %v", e.Target, list)
+	}
+
+	line := fmt.Sprintf("%v was listed on %v", e.Target, list)
+	if len(e.ReturnCodes) > 0 {
+		line += ": " + formatReturnCodes(e.ReturnCodes)
+	}
+	for _, txt := range e.TXT {
+		line += "\n    " + txt
+	}
+
+	return line
+}
+
+// emailMessage returns an RFC 5322 email from `from` to `to` that describes `events`
+func emailMessage(from string, to []string, events []dnsbl.Event, date time.Time) []byte {
+	listed, delisted := 0, 0
+	lines := make([]string, 0, len(events))
+	for _, e := range events {
+		if e.Type == dnsbl.EventListed {
+			listed++
+		} else {
+			delisted++
+		}
+		lines = append(lines, describeEvent(e))
+	}
+
+	var subject string
+	switch {
+	case len(events) == 1 && listed == 1:
+		subject = fmt.Sprintf("%v listed on %v", events[0].Target, events[0].List.Address)
+	case len(events) == 1:
+		subject = fmt.Sprintf("%v removed from %v", events[0].Target, events[0].List.Address)
+	default:
+		subject = fmt.Sprintf("%v new listings, %v delistings", listed, delisted)
+	}
+
+	msg := &strings.Builder{}
+	fmt.Fprintf(msg, "From: %v\r\n", from)
+	fmt.Fprintf(msg, "To: %v\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(msg, "Subject: DNSBL: %v\r\n", subject)
+	fmt.Fprintf(msg, "Date: %v\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(msg, "\r\n")
+	fmt.Fprintf(msg, "%v\r\n", strings.ReplaceAll(strings.Join(lines, "\n"), "\n", "\r\n"))
+
+	return []byte(msg.String())
+}
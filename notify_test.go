@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+var testEvents = []dnsbl.Event{
+	{
+		Type:        dnsbl.EventListed,
+		Target:      "192.0.2.1",
+		List:        &dnsbl.ListItem{Name: "SpamCop", Address: "bl.spamcop.net"},
+		Time:        time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		ReturnCodes: []dnsbl.ReturnCode{{Code: "127.0.0.2"}},
+		TXT:         []string{"Blocked - see https://www.spamcop.net/bl.shtml?192.0.2.1"},
+	},
+	{
+		Type:   dnsbl.EventDelisted,
+		Target: "192.0.2.1",
+		List:   &dnsbl.ListItem{Address: "bl.example.com"},
+		Time:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+	},
+}
+
+func Test_webhookNotifier(t *testing.T) {
+	var got jsonNotification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	n := &webhookNotifier{url: srv.URL, client: srv.Client()}
+	if err := n.notify(testEvents); err != nil {
+		t.Fatalf("notify() error = %v", err)
+	}
+
+	if len(got.Events) != 2 || got.Events[0].Event != "listed" || got.Events[0].Name != "SpamCop" ||
+		got.Events[0].ReturnCodes[0].Code != "127.0.0.2" || got.Events[1].Event != "delisted" {
+		t.Errorf("notify() payload = %+v", got)
+	}
+}
+
+func Test_slackNotifier(t *testing.T) {
+	var got map[string]string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	n := &slackNotifier{url: srv.URL, client: srv.Client()}
+	if err := n.notify(testEvents); err != nil {
+		t.Fatalf("notify() error = %v", err)
+	}
+
+	want := "192.0.2.1 was listed on SpamCop (bl.spamcop.net): 127.0.0.2\n    Blocked - see https://www.spamcop.net/bl.shtml?192.0.2.1\n192.0.2.1 was removed from bl.example.com"
+	if got["text"] != want {
+		t.Errorf("notify() text = %q, want %q", got["text"], want)
+	}
+
+	status = http.StatusForbidden
+	if err := n.notify(testEvents); err == nil {
+		t.Errorf("notify() error = nil, want error for %v", status)
+	}
+}
+
+func Test_emailMessage(t *testing.T) {
+	date := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	msg := string(emailMessage("dnsbl@example.com", []string{"a@example.com", "b@example.com"}, testEvents, date))
+	for _, want := range []string{
+		"From: dnsbl@example.com\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: DNSBL: 1 new listings, 1 delistings\r\n",
+		"Date: Thu, 02 Jan 2020 03:04:05 +0000\r\n",
+		"\r\n\r\n192.0.2.1 was listed on SpamCop (bl.spamcop.net): 127.0.0.2\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("emailMessage() = %q, missing %q", msg, want)
+		}
+	}
+
+	msg = string(emailMessage("dnsbl@example.com", []string{"a@example.com"}, testEvents[:1], date))
+	if !strings.Contains(msg, "Subject: DNSBL: 192.0.2.1 listed on bl.spamcop.net\r\n") {
+		t.Errorf("emailMessage() = %q, want single event subject", msg)
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+type jsonReport struct {
+	Target     string        `json:"target"`
+	Timestamp  time.Time     `json:"timestamp"`
+	DurationMS int64         `json:"duration_ms"`
+	Results    []*jsonResult `json:"results"`
+	Summary    jsonSummary   `json:"summary"`
+	Score      float64       `json:"score"`
+	Severity   string        `json:"severity"`
+}
+
+type jsonResult struct {
+	List        string             `json:"list"`
+	Name        string             `json:"name"`
+	Status      string             `json:"status"`
+	ReturnCodes []dnsbl.ReturnCode `json:"return_codes,omitempty"`
+	TXT         []string           `json:"txt,omitempty"`
+	LatencyMS   int64              `json:"latency_ms"`
+	Error       string             `json:"error,omitempty"`
+	Weight      float64            `json:"weight"`
+}
+
+type jsonSummary struct {
+	Checks    int `json:"checks"`
+	Hits      int `json:"hits"`
+	Misses    int `json:"misses"`
+	Timeouts  int `json:"timeouts"`
+	Failures  int `json:"failures"`
+	Blocked   int `json:"blocked"`
+	Unhealthy int `json:"unhealthy"`
+	ServFails int `json:"servfails"`
+	Refused   int `json:"refused"`
+}
+
+type jsonBatchReport struct {
+	Timestamp  time.Time        `json:"timestamp"`
+	DurationMS int64            `json:"duration_ms"`
+	Targets    []*jsonReport    `json:"targets"`
+	Summary    jsonBatchSummary `json:"summary"`
+}
+
+type jsonBatchSummary struct {
+	Targets  int     `json:"targets"`
+	Listed   int     `json:"listed"`
+	MaxScore float64 `json:"max_score"`
+	Severity string  `json:"severity"`
+	jsonSummary
+}
+
+type jsonRangeReport struct {
+	Range      string           `json:"range"`
+	Timestamp  time.Time        `json:"timestamp"`
+	DurationMS int64            `json:"duration_ms"`
+	Lists      []string         `json:"lists"`
+	Matrix     []*rangeRow      `json:"matrix"`
+	Summary    jsonBatchSummary `json:"summary"`
+}
+
+// rangeRow is a single address of a range and the lists it is listed on
+type rangeRow struct {
+	Address string   `json:"address"`
+	Listed  []string `json:"listed"`
+}
+
+// printReport prints the result of every check followed by a summary
+func printReport(report *dnsbl.Report) {
+	for _, res := range report.Results {
+		switch res.Status {
+		case dnsbl.StatusHit:
+			fmt.Printf("%v : HIT (%v)\n", res.List.Address, formatReturnCodes(res.ReturnCodes))
+			for _, txt := range res.TXT {
+				fmt.Printf("    %v\n", txt)
+			}
+		case dnsbl.StatusBlocked:
+			fmt.Printf("%v : BLOCKED (%v)\n", res.List.Address, formatReturnCodes(res.ReturnCodes))
+		case dnsbl.StatusFailure, dnsbl.StatusUnhealthy, dnsbl.StatusServFail, dnsbl.StatusRefused:
+			if *cfgVerbose {
+				fmt.Printf("%v : %v: %v\n", res.List.Address, res.Status, res.Err)
+			}
+		default:
+			if *cfgVerbose {
+				fmt.Printf("%v : %v\n", res.List.Address, res.Status)
+			}
+		}
+	}
+
+	fmt.Printf("------------------------------------------------\n")
+	fmt.Printf("Result: %v checks performed. %v hits, %v misses, %v timeouts, %v failures, %v servfail, %v refused, %v unhealthy, %v blocked\n",
+		report.Checks, report.Hits, report.Misses, report.Timeouts, report.Failures, report.ServFails, report.Refused, report.Unhealthy, report.Blocked)
+	if !*cfgWhitelist {
+		fmt.Printf("Score: %v (%v)\n", report.Score, reportSeverity(report))
+	}
+}
+
+// printJSON prints `report` as a single JSON document
+func printJSON(report *dnsbl.Report) {
+	writeJSON(newJSONReport(report))
+}
+
+// writeJSON prints `v` as indented JSON
+func writeJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// printInfo prints the answers of all informational lists that know about the target
+func printInfo(report *dnsbl.Report) {
+	found := 0
+	for _, res := range report.Results {
+		switch {
+		case res.Status == dnsbl.StatusHit:
+			found++
+			answers := res.TXT
+			if len(answers) == 0 {
+				answers = []string{formatReturnCodes(res.ReturnCodes)}
+			}
+			fmt.Printf("%v : %v\n", res.List.Address, strings.Join(answers, " | "))
+		case *cfgVerbose && res.Err != nil:
+			fmt.Printf("%v : %v: %v\n", res.List.Address, res.Status, res.Err)
+		case *cfgVerbose:
+			fmt.Printf("%v : %v\n", res.List.Address, res.Status)
+		}
+	}
+
+	fmt.Printf("------------------------------------------------\n")
+	fmt.Printf("Result: %v informational lists queried, %v returned details about %v\n", report.Checks, found, report.Target)
+}
+
+// printBatchReport prints the report of every target followed by an overall summary
+func printBatchReport(batch *dnsbl.BatchReport) {
+	for _, report := range batch.Reports {
+		fmt.Printf("=== %v ===\n", report.Target)
+		printReport(report)
+		fmt.Println()
+	}
+
+	fmt.Printf("================================================\n")
+	fmt.Printf("Total: %v targets checked, %v listed. %v checks performed. %v hits, %v misses, %v timeouts, %v failures, %v servfail, %v refused, %v unhealthy, %v blocked\n",
+		batch.Targets, batch.Listed, batch.Checks, batch.Hits, batch.Misses, batch.Timeouts, batch.Failures, batch.ServFails, batch.Refused, batch.Unhealthy, batch.Blocked)
+	if !*cfgWhitelist {
+		fmt.Printf("Highest score: %v (%v)\n", batch.MaxScore, batchSeverity(batch))
+	}
+}
+
+// printBatchJSON prints `batch` as a single JSON document
+func printBatchJSON(batch *dnsbl.BatchReport) {
+	out := &jsonBatchReport{
+		Timestamp:  batch.Time.UTC(),
+		DurationMS: batch.Duration.Milliseconds(),
+		Targets:    make([]*jsonReport, 0, len(batch.Reports)),
+		Summary:    newJSONBatchSummary(batch),
+	}
+
+	for _, report := range batch.Reports {
+		out.Targets = append(out.Targets, newJSONReport(report))
+	}
+
+	writeJSON(out)
+}
+
+// printRangeMatrix prints a matrix of the listed addresses of a range and the lists they are listed on,
+// followed by an overall summary. Addresses and lists without hits are left out.
+func printRangeMatrix(batch *dnsbl.BatchReport) {
+	lists, rows := rangeMatrix(batch)
+
+	if len(rows) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "ADDRESS\t%v\n", strings.Join(lists, "\t"))
+		for _, row := range rows {
+			cells := make([]string, 0, len(lists))
+			for _, list := range lists {
+				if isStringInSlice(list, row.Listed) {
+					cells = append(cells, "X")
+				} else {
+					cells = append(cells, ".")
+				}
+			}
+			fmt.Fprintf(w, "%v\t%v\n", row.Address, strings.Join(cells, "\t"))
+		}
+		w.Flush()
+	}
+
+	fmt.Printf("------------------------------------------------\n")
+	fmt.Printf("Result: %v addresses checked, %v listed. %v checks performed. %v hits, %v misses, %v timeouts, %v failures, %v servfail, %v refused, %v unhealthy, %v blocked\n",
+		batch.Targets, batch.Listed, batch.Checks, batch.Hits, batch.Misses, batch.Timeouts, batch.Failures, batch.ServFails, batch.Refused, batch.Unhealthy, batch.Blocked)
+	if !*cfgWhitelist {
+		fmt.Printf("Highest score: %v (%v)\n", batch.MaxScore, batchSeverity(batch))
+	}
+}
+
+// printRangeJSON prints the matrix of range `cidr` as a single JSON document
+func printRangeJSON(cidr string, batch *dnsbl.BatchReport) {
+	lists, rows := rangeMatrix(batch)
+
+	writeJSON(&jsonRangeReport{
+		Range:      cidr,
+		Timestamp:  batch.Time.UTC(),
+		DurationMS: batch.Duration.Milliseconds(),
+		Lists:      lists,
+		Matrix:     rows,
+		Summary:    newJSONBatchSummary(batch),
+	})
+}
+
+// rangeMatrix returns the lists with at least one hit, in the order they were checked, and
+// the addresses listed on them
+func rangeMatrix(batch *dnsbl.BatchReport) ([]string, []*rangeRow) {
+	lists := []string{}
+	rows := []*rangeRow{}
+
+	for _, report := range batch.Reports {
+		row := &rangeRow{Address: report.Target, Listed: []string{}}
+		for _, res := range report.Results {
+			if res.Status != dnsbl.StatusHit {
+				continue
+			}
+			row.Listed = append(row.Listed, res.List.Address)
+			if !isStringInSlice(res.List.Address, lists) {
+				lists = append(lists, res.List.Address)
+			}
+		}
+		if len(row.Listed) > 0 {
+			rows = append(rows, row)
+		}
+	}
+
+	return lists, rows
+}
+
+// newJSONBatchSummary returns the JSON representation of the counters of `batch`
+func newJSONBatchSummary(batch *dnsbl.BatchReport) jsonBatchSummary {
+	return jsonBatchSummary{
+		Targets:  batch.Targets,
+		Listed:   batch.Listed,
+		MaxScore: batch.MaxScore,
+		Severity: batchSeverity(batch).String(),
+		jsonSummary: jsonSummary{
+			Checks:    batch.Checks,
+			Hits:      batch.Hits,
+			Misses:    batch.Misses,
+			Timeouts:  batch.Timeouts,
+			Failures:  batch.Failures,
+			Blocked:   batch.Blocked,
+			Unhealthy: batch.Unhealthy,
+			ServFails: batch.ServFails,
+			Refused:   batch.Refused,
+		},
+	}
+}
+
+// newJSONReport converts `report` to its JSON representation
+func newJSONReport(report *dnsbl.Report) *jsonReport {
+	out := &jsonReport{
+		Target:     report.Target,
+		Timestamp:  report.Time.UTC(),
+		DurationMS: report.Duration.Milliseconds(),
+		Results:    make([]*jsonResult, 0, len(report.Results)),
+		Score:      report.Score,
+		Severity:   reportSeverity(report).String(),
+		Summary: jsonSummary{
+			Checks:    report.Checks,
+			Hits:      report.Hits,
+			Misses:    report.Misses,
+			Timeouts:  report.Timeouts,
+			Failures:  report.Failures,
+			Blocked:   report.Blocked,
+			Unhealthy: report.Unhealthy,
+			ServFails: report.ServFails,
+			Refused:   report.Refused,
+		},
+	}
+
+	for _, res := range report.Results {
+		jr := &jsonResult{
+			List:        res.List.Address,
+			Name:        res.List.Name,
+			Status:      strings.ToLower(res.Status.String()),
+			ReturnCodes: res.ReturnCodes,
+			TXT:         res.TXT,
+			LatencyMS:   res.Duration.Milliseconds(),
+			Weight:      res.List.EffectiveWeight(),
+		}
+		if res.Err != nil {
+			jr.Error = res.Err.Error()
+		}
+		out.Results = append(out.Results, jr)
+	}
+
+	return out
+}
+
+// formatReturnCodes returns `codes` as a comma separated list of codes and their meaning
+func formatReturnCodes(codes []dnsbl.ReturnCode) string {
+	out := make([]string, 0, len(codes))
+	for _, rc := range codes {
+		if rc.Meaning == "" {
+			out = append(out, rc.Code)
+			continue
+		}
+		out = append(out, rc.Code+" "+rc.Meaning)
+	}
+
+	return strings.Join(out, ", ")
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+func Test_newJSONReport(t *testing.T) {
+	list := &dnsbl.ListItem{Name: "Example BL", Address: "bl.example.com"}
+	report := &dnsbl.Report{
+		Target: "192.0.2.1",
+		Time:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Results: []*dnsbl.Result{
+			{List: list, Status: dnsbl.StatusHit, ReturnCodes: []dnsbl.ReturnCode{{Code: "127.0.0.2"}}, Duration: 25 * time.Millisecond},
+			{List: list, Status: dnsbl.StatusUnhealthy, Err: errors.New("RBL failed positive check")},
+		},
+		Checks: 2,
+		Hits:   1,
+	}
+
+	got := newJSONReport(report)
+
+	if got.Target != "192.0.2.1" || !got.Timestamp.Equal(report.Time) || got.Summary.Checks != 2 || got.Summary.Hits != 1 {
+		t.Errorf("newJSONReport() = %+v", got)
+	}
+	if r := got.Results[0]; r.Status != "hit" || r.LatencyMS != 25 || r.ReturnCodes[0].Code != "127.0.0.2" || r.Error != "" {
+		t.Errorf("newJSONReport() Results[0] = %+v", r)
+	}
+	if r := got.Results[1]; r.Status != "unhealthy" || r.Error != "RBL failed positive check" {
+		t.Errorf("newJSONReport() Results[1] = %+v", r)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	valid "github.com/asaskevich/govalidator"
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+// apiServer answers REST API requests with checks performed by a shared Checker
+type apiServer struct {
+	checker *dnsbl.Checker
+	// token is the bearer token required by every request. Empty if authentication is disabled.
+	token string
+	// timeout is how long a request may wait for a free slot and its check
+	timeout time.Duration
+	// slots limits the number of checks running at the same time across all requests
+	slots chan struct{}
+}
+
+type jsonError struct {
+	Error string `json:"error"`
+}
+
+// runServe serves the REST API on --listen until the program is killed
+func runServe(checker *dnsbl.Checker) {
+	if *cfgMaxConcurrent < 1 {
+		app.FatalUsage("--max-concurrent must be at least 1")
+	}
+
+	s := newAPIServer(checker, *cfgAPIToken, *cfgRequestTimeout, *cfgMaxConcurrent)
+
+	// lists' health can change while the server is running
+	resetEvery := *cfgHealthTTL
+	if resetEvery <= 0 {
+		resetEvery = time.Hour
+	}
+	go func() {
+		for range time.Tick(resetEvery) {
+			checker.ResetHealth()
+			saveHealthCache(checker)
+		}
+	}()
+
+	if err := http.ListenAndServe(*cfgServeListen, s); err != nil {
+		app.Fatalf("%v", err)
+	}
+}
+
+// newAPIServer returns an apiServer that runs at most `maxConcurrent` checks at the same time
+func newAPIServer(checker *dnsbl.Checker, token string, timeout time.Duration, maxConcurrent int) *apiServer {
+	return &apiServer{
+		checker: checker,
+		token:   token,
+		timeout: timeout,
+		slots:   make(chan struct{}, maxConcurrent),
+	}
+}
+
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dnsbl_checker"`)
+			writeAPIError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeAPIError(w, http.StatusMethodNotAllowed, "only GET is supported")
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/lists":
+		q := r.URL.Query()
+		writeAPIJSON(w, http.StatusOK, newJSONLists(filterCatalogue(s.checker.Lists, q.Get("capability"), q.Get("type"), q.Get("search"))))
+
+	case strings.HasPrefix(r.URL.Path, "/check/ip/"):
+		ip := strings.TrimPrefix(r.URL.Path, "/check/ip/")
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			writeAPIError(w, http.StatusBadRequest, "not a valid IP address: "+ip)
+			return
+		}
+		if parsed.To4() != nil {
+			// IPv4-mapped IPv6 addresses are checked in their IPv4 form
+			ip = parsed.To4().String()
+			s.check(w, r, func(ctx context.Context, whitelist bool) *dnsbl.Report {
+				return s.checker.CheckIP4Context(ctx, whitelist, ip)
+			})
+		} else {
+			s.check(w, r, func(ctx context.Context, whitelist bool) *dnsbl.Report {
+				return s.checker.CheckIP6Context(ctx, whitelist, ip)
+			})
+		}
+
+	case strings.HasPrefix(r.URL.Path, "/check/domain/"):
+		domain := strings.TrimPrefix(r.URL.Path, "/check/domain/")
+		if !valid.IsDNSName(domain) {
+			writeAPIError(w, http.StatusBadRequest, "not a valid domain name: "+domain)
+			return
+		}
+		s.check(w, r, func(ctx context.Context, whitelist bool) *dnsbl.Report {
+			return s.checker.CheckDomainContext(ctx, whitelist, domain)
+		})
+
+	default:
+		writeAPIError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// check runs `fn` in a free slot and writes its report. Whitelists are checked if the
+// request has a whitelist=true query parameter. The queries of `fn` are aborted when
+// the request times out or the client goes away.
+func (s *apiServer) check(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, whitelist bool) *dnsbl.Report) {
+	whitelist, _ := strconv.ParseBool(r.URL.Query().Get("whitelist"))
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		if r.Context().Err() == nil {
+			writeAPIError(w, http.StatusServiceUnavailable, "too many checks in progress")
+		}
+		return
+	}
+
+	done := make(chan *dnsbl.Report, 1)
+	go func() {
+		// the slot is held until the check finishes, even if the request gave up on it
+		defer func() { <-s.slots }()
+		done <- fn(ctx, whitelist)
+	}()
+
+	select {
+	case report := <-done:
+		writeAPIJSON(w, http.StatusOK, newJSONReport(report))
+	case <-ctx.Done():
+		if r.Context().Err() == nil {
+			writeAPIError(w, http.StatusGatewayTimeout, "check did not finish in time")
+		}
+	}
+}
+
+// writeAPIJSON writes `v` as the JSON response with `status`
+func writeAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Could not write response: %v", err)
+	}
+}
+
+// writeAPIError writes `msg` as a JSON error response with `status`
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	writeAPIJSON(w, status, &jsonError{Error: msg})
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maticmeznar/dnsbl_checker/dnsbl"
+)
+
+// stubResolver answers queries from a static map after waiting for `delay`
+type stubResolver struct {
+	hosts map[string][]string
+	delay time.Duration
+}
+
+func (r *stubResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	time.Sleep(r.delay)
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (r *stubResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func newTestAPIServer(delay time.Duration, token string) *apiServer {
+	checker := dnsbl.NewChecker([]*dnsbl.ListItem{
+		{Name: "Example BL", Address: "bl.example.com", IP4: true, Blacklist: true},
+		{Name: "Example DBL", Address: "dbl.example.com", Domain: true, Blacklist: true},
+	})
+	checker.Resolver = &stubResolver{delay: delay, hosts: map[string][]string{
+		"2.0.0.127.bl.example.com": {"127.0.0.2"},
+		"1.2.0.192.bl.example.com": {"127.0.0.2"},
+		"TEST.dbl.example.com":     {"127.0.1.2"},
+	}}
+
+	return newAPIServer(checker, token, 200*time.Millisecond, 1)
+}
+
+func Test_apiServer(t *testing.T) {
+	s := newTestAPIServer(0, "secret")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"listed IP", "GET", "/check/ip/192.0.2.1", "secret", http.StatusOK},
+		{"IPv4-mapped IP", "GET", "/check/ip/::ffff:102:304", "secret", http.StatusOK},
+		{"domain", "GET", "/check/domain/example.com", "secret", http.StatusOK},
+		{"lists", "GET", "/lists?capability=domain", "secret", http.StatusOK},
+		{"invalid IP", "GET", "/check/ip/192.0.2", "secret", http.StatusBadRequest},
+		{"invalid domain", "GET", "/check/domain/-", "secret", http.StatusBadRequest},
+		{"unknown path", "GET", "/check/asn/64496", "secret", http.StatusNotFound},
+		{"wrong method", "POST", "/lists", "secret", http.StatusMethodNotAllowed},
+		{"missing token", "GET", "/lists", "", http.StatusUnauthorized},
+		{"wrong token", "GET", "/lists", "public", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("ServeHTTP() status = %v, want %v: %v", rec.Code, tt.want, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("ServeHTTP() Content-Type = %v", ct)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/check/ip/192.0.2.1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	s.ServeHTTP(rec, req)
+	var report jsonReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil || report.Target != "192.0.2.1" || report.Summary.Hits != 1 {
+		t.Errorf("ServeHTTP() report = %+v, %v", report, err)
+	}
+
+	// ::ffff:c000:201 is 192.0.2.1
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/check/ip/::ffff:c000:201", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	s.ServeHTTP(rec, req)
+	report = jsonReport{}
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil || report.Target != "192.0.2.1" || report.Summary.Hits != 1 {
+		t.Errorf("ServeHTTP() report of IPv4-mapped address = %+v, %v", report, err)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/lists?capability=domain", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	s.ServeHTTP(rec, req)
+	var lists []jsonList
+	if err := json.NewDecoder(rec.Body).Decode(&lists); err != nil || len(lists) != 1 || lists[0].Address != "dbl.example.com" {
+		t.Errorf("ServeHTTP() lists = %+v, %v", lists, err)
+	}
+}
+
+func Test_apiServer_timeouts(t *testing.T) {
+	s := newTestAPIServer(time.Second, "")
+
+	// the first request times out but keeps the only slot until its check finishes
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/check/ip/192.0.2.1", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("ServeHTTP() status = %v, want %v", rec.Code, http.StatusGatewayTimeout)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/check/ip/192.0.2.1", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("ServeHTTP() status = %v, want %v", rec.Code, http.StatusServiceUnavailable)
+	}
+}